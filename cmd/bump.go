@@ -2,14 +2,18 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/arzkar/git-utils/utils"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
 var bumpCmd = &cobra.Command{
-	Use:   "bump",
+	Use:   "bump [part]",
 	Short: "Version bump the version",
+	Long:  "Version bump the version, or the given part of a custom version scheme",
+	Args:  cobra.MaximumNArgs(1),
 	Run:   bumpVersion,
 }
 
@@ -31,196 +35,170 @@ var patchCmd = &cobra.Command{
 	Run:   bumpPatch,
 }
 
+var prereleaseCmd = &cobra.Command{
+	Use:   "prerelease",
+	Short: "Version bump the pre-release number (1.2.3-rc.1 → 1.2.3-rc.2)",
+	Run:   bumpPrerelease,
+}
+
+var preCmd = &cobra.Command{
+	Use:   "pre",
+	Short: "Version bump to a pre-release label (1.2.3-alpha.2 → 1.2.3-beta.1)",
+	Run:   bumpPre,
+}
+
+var releaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Finalize the pre-release version (1.2.3-rc.2 → 1.2.3)",
+	Run:   bumpRelease,
+}
+
+var calverCmd = &cobra.Command{
+	Use:   "calver",
+	Short: "Version bump to the calendar version of today (YYYY.MM.MICRO)",
+	Run:   bumpCalVer,
+}
+
 func init() {
 	rootCmd.AddCommand(bumpCmd)
 	bumpCmd.AddCommand(majorCmd)
 	bumpCmd.AddCommand(minorCmd)
 	bumpCmd.AddCommand(patchCmd)
+	bumpCmd.AddCommand(prereleaseCmd)
+	bumpCmd.AddCommand(preCmd)
+	bumpCmd.AddCommand(releaseCmd)
+	bumpCmd.AddCommand(calverCmd)
+
+	for _, cmd := range []*cobra.Command{bumpCmd, majorCmd, minorCmd, patchCmd} {
+		cmd.Flags().String("pre", "", "Start a pre-release of the new version with the label (e.g. rc)")
+	}
+	bumpCmd.PersistentFlags().Bool("dry-run", false, "Show the changes without writing, committing or tagging anything")
+	bumpCmd.PersistentFlags().Bool("allow-dirty", false, "Bump even if the repository has changes, untracked target files or an operation in progress")
+	preCmd.Flags().StringP("label", "l", "", "Pre-release label (e.g. alpha, beta or rc)")
+	preCmd.MarkFlagRequired("label")
 }
 
 func bumpVersion(cmd *cobra.Command, args []string) {
-	currentVersion, err := utils.GetCurrentVersion()
-	if err != nil {
-		fmt.Println("Failed to read current version:", err)
-		return
-	}
-
-	newVersion := utils.IncrementVersion(currentVersion)
-
-	err = utils.UpdateFiles(currentVersion, newVersion)
-	if err != nil {
-		fmt.Println("Failed to update files:", err)
-		return
-	}
-
-	commitEnabled, err := utils.GetCommitOption()
-	if err != nil {
-		fmt.Println("Failed to read commit option:", err)
-		return
-	}
-	commitMessage := fmt.Sprintf("Bump version: %s → %s", currentVersion, newVersion)
-	if commitEnabled {
-		err = utils.CommitChanges(currentVersion, newVersion, commitMessage)
-		if err != nil {
-			fmt.Println("Failed to commit changes:", err)
-			return
-		}
-	}
-
-	tagEnabled, err := utils.GetTagOption()
-	if err != nil {
-		fmt.Println("Failed to read tag option:", err)
+	if len(args) == 1 {
+		part := args[0]
+		bumpPart(cmd, part, func(currentVersion string) (string, error) {
+			return utils.BumpVersionPart(currentVersion, part)
+		})
 		return
 	}
 
-	if tagEnabled {
-		err = utils.CreateTag(newVersion, commitMessage)
-		if err != nil {
-			fmt.Println("Failed to create tag:", err)
-			return
-		}
-	}
-
-	fmt.Printf("Bump version: %s → %s\n", currentVersion, newVersion)
+	bumpPart(cmd, "patch", utils.IncrementVersion)
 }
 
 func bumpMajor(cmd *cobra.Command, args []string) {
-	currentVersion, err := utils.GetCurrentVersion()
-	if err != nil {
-		fmt.Println("Failed to read current version:", err)
-		return
-	}
-
-	newVersion := utils.BumpMajorVersion(currentVersion)
+	bumpPart(cmd, "major", utils.BumpMajorVersion)
+}
 
-	err = utils.UpdateFiles(currentVersion, newVersion)
-	if err != nil {
-		fmt.Println("Failed to update files:", err)
-		return
-	}
+func bumpMinor(cmd *cobra.Command, args []string) {
+	bumpPart(cmd, "minor", utils.BumpMinorVersion)
+}
 
-	commitEnabled, err := utils.GetCommitOption()
-	if err != nil {
-		fmt.Println("Failed to read commit option:", err)
-		return
-	}
+func bumpPatch(cmd *cobra.Command, args []string) {
+	bumpPart(cmd, "patch", utils.BumpPatchVersion)
+}
 
-	commitMessage := fmt.Sprintf("Bump version: %s → %s", currentVersion, newVersion)
-	if commitEnabled {
-		err = utils.CommitChanges(currentVersion, newVersion, commitMessage)
-		if err != nil {
-			fmt.Println("Failed to commit changes:", err)
-			return
-		}
-	}
+func bumpPrerelease(cmd *cobra.Command, args []string) {
+	runBump(cmd, utils.BumpPrereleaseVersion)
+}
 
-	tagEnabled, err := utils.GetTagOption()
-	if err != nil {
-		fmt.Println("Failed to read tag option:", err)
-		return
-	}
+func bumpPre(cmd *cobra.Command, args []string) {
+	label, _ := cmd.Flags().GetString("label")
+	runBump(cmd, func(currentVersion string) (string, error) {
+		return utils.BumpPrereleaseLabel(currentVersion, label)
+	})
+}
 
-	if tagEnabled {
-		err = utils.CreateTag(newVersion, commitMessage)
-		if err != nil {
-			fmt.Println("Failed to create tag:", err)
-			return
-		}
-	}
+func bumpRelease(cmd *cobra.Command, args []string) {
+	runBump(cmd, utils.ReleaseVersion)
+}
 
-	fmt.Printf("Bump version: %s → %s\n", currentVersion, newVersion)
+func bumpCalVer(cmd *cobra.Command, args []string) {
+	runBump(cmd, utils.BumpCalVer)
 }
 
-func bumpMinor(cmd *cobra.Command, args []string) {
-	currentVersion, err := utils.GetCurrentVersion()
-	if err != nil {
-		fmt.Println("Failed to read current version:", err)
+// bumpPart bumps a version part, starting a pre-release when --pre is set
+func bumpPart(cmd *cobra.Command, part string, bump func(currentVersion string) (string, error)) {
+	pre, _ := cmd.Flags().GetString("pre")
+	if pre == "" {
+		runBump(cmd, bump)
 		return
 	}
 
-	newVersion := utils.BumpMinorVersion(currentVersion)
+	runBump(cmd, func(currentVersion string) (string, error) {
+		return utils.BumpPartWithPrerelease(currentVersion, part, pre)
+	})
+}
 
-	err = utils.UpdateFiles(currentVersion, newVersion)
-	if err != nil {
-		fmt.Println("Failed to update files:", err)
-		return
-	}
+// runBump bumps the version with the bump pipeline, or previews the bump
+// with --dry-run
+func runBump(cmd *cobra.Command, bump func(currentVersion string) (string, error)) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	allowDirty, _ := cmd.Flags().GetBool("allow-dirty")
 
-	commitEnabled, err := utils.GetCommitOption()
+	pipeline, err := utils.NewBumpPipeline(bump)
 	if err != nil {
-		fmt.Println("Failed to read commit option:", err)
-		return
+		fmt.Println("Failed to bump version:", err)
+		os.Exit(1)
 	}
-
-	commitMessage := fmt.Sprintf("Bump version: %s → %s", currentVersion, newVersion)
-	if commitEnabled {
-		err = utils.CommitChanges(currentVersion, newVersion, commitMessage)
-		if err != nil {
-			fmt.Println("Failed to commit changes:", err)
-			return
-		}
+	if allowDirty {
+		pipeline.AllowDirty = true
 	}
 
-	tagEnabled, err := utils.GetTagOption()
-	if err != nil {
-		fmt.Println("Failed to read tag option:", err)
+	if dryRun {
+		previewBump(pipeline)
 		return
 	}
 
-	if tagEnabled {
-		err = utils.CreateTag(newVersion, commitMessage)
-		if err != nil {
-			fmt.Println("Failed to create tag:", err)
-			return
-		}
-	}
-
-	fmt.Printf("Bump version: %s → %s\n", currentVersion, newVersion)
-}
-
-func bumpPatch(cmd *cobra.Command, args []string) {
-	currentVersion, err := utils.GetCurrentVersion()
+	err = pipeline.Run()
 	if err != nil {
-		fmt.Println("Failed to read current version:", err)
-		return
+		fmt.Println("Failed to bump version:", err)
+		os.Exit(1)
 	}
 
-	newVersion := utils.BumpPatchVersion(currentVersion)
+	fmt.Printf("Bump version: %s → %s\n", pipeline.CurrentVersion, pipeline.NewVersion)
+}
 
-	err = utils.UpdateFiles(currentVersion, newVersion)
+// previewBump prints the changes of the bump without applying them
+func previewBump(pipeline *utils.BumpPipeline) {
+	updates, err := pipeline.Plan()
 	if err != nil {
 		fmt.Println("Failed to update files:", err)
-		return
+		os.Exit(1)
 	}
 
-	commitEnabled, err := utils.GetCommitOption()
-	if err != nil {
-		fmt.Println("Failed to read commit option:", err)
-		return
+	fmt.Printf("Bump version: %s → %s (dry run)\n", pipeline.CurrentVersion, pipeline.NewVersion)
+	if err := pipeline.CheckWorkingTree(); err != nil {
+		fmt.Println(color.YellowString("Warning: %s", err))
+	}
+	for _, update := range updates {
+		fmt.Println()
+		fmt.Print(utils.ColorizeDiff(update.Diff()))
 	}
 
-	commitMessage := fmt.Sprintf("Bump version: %s → %s", currentVersion, newVersion)
-	if commitEnabled {
-		err = utils.CommitChanges(currentVersion, newVersion, commitMessage)
-		if err != nil {
-			fmt.Println("Failed to commit changes:", err)
-			return
+	fmt.Println()
+	for _, hook := range utils.BumpHooks {
+		if !pipeline.HookEnabled(hook) {
+			continue
+		}
+		for _, command := range pipeline.Hooks[hook] {
+			fmt.Printf("Would run %s hook: %s\n", hook, command)
 		}
 	}
-
-	tagEnabled, err := utils.GetTagOption()
-	if err != nil {
-		fmt.Println("Failed to read tag option:", err)
-		return
+	if pipeline.Commit {
+		fmt.Println("Would commit:", pipeline.CommitMessage)
 	}
-
-	if tagEnabled {
-		err = utils.CreateTag(newVersion, commitMessage)
-		if err != nil {
-			fmt.Println("Failed to create tag:", err)
-			return
+	if pipeline.Tag {
+		if err := pipeline.RenderTagMessage(); err != nil {
+			fmt.Println("Failed to bump version:", err)
+			os.Exit(1)
 		}
+		fmt.Println("Would tag:", pipeline.TagName)
+		fmt.Println(color.YellowString("(approximate message, rendered before the bump commit)"))
+		fmt.Println(pipeline.TagMessage)
 	}
-
-	fmt.Printf("Bump version: %s → %s\n", currentVersion, newVersion)
 }
@@ -16,11 +16,14 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"sort"
 	"strings"
+	"text/tabwriter"
+
+	"github.com/arzkar/git-utils/utils"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -30,21 +33,77 @@ var grepCmd *cobra.Command
 
 func init() {
 	grepCmd = &cobra.Command{
-		Use:   "grep",
+		Use:   "grep [pattern]",
 		Short: "Search for a pattern in files",
 		Long:  "Recursively search for a pattern in files within the specified directory",
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MaximumNArgs(1),
 		Run:   runGrep,
 	}
 
-	var dir string
+	var dir, format, saved string
+	var context int
+	var include, exclude []string
+	var listSaved bool
 	grepCmd.Flags().StringVarP(&dir, "dir", "d", "", "Directory to search in")
+	grepCmd.Flags().StringVarP(&format, "format", "f", "text", "Output format: text, json, vimgrep, summary or sarif")
+	grepCmd.Flags().IntVarP(&context, "context", "C", 2, "Number of surrounding lines included in json output")
+	grepCmd.Flags().StringSliceVar(&include, "include", nil, "Only search files matching these pathspecs (e.g. '*.go')")
+	grepCmd.Flags().StringSliceVar(&exclude, "exclude", nil, "Skip files matching these pathspecs")
+	grepCmd.Flags().StringVar(&saved, "saved", "", "Run a query saved in the config file")
+	grepCmd.Flags().BoolVar(&listSaved, "list-saved", false, "List the queries saved in the config file")
 	rootCmd.AddCommand(grepCmd)
 }
 
 func runGrep(cmd *cobra.Command, args []string) {
-	pattern := args[0]
 	dir, _ := cmd.Flags().GetString("dir")
+	format, _ := cmd.Flags().GetString("format")
+	context, _ := cmd.Flags().GetInt("context")
+	include, _ := cmd.Flags().GetStringSlice("include")
+	exclude, _ := cmd.Flags().GetStringSlice("exclude")
+	saved, _ := cmd.Flags().GetString("saved")
+	listSaved, _ := cmd.Flags().GetBool("list-saved")
+
+	if listSaved {
+		listSavedGrepQueries()
+		return
+	}
+
+	var query utils.GrepQuery
+	if saved != "" {
+		if len(args) != 0 {
+			fmt.Println("A pattern can't be used together with --saved")
+			os.Exit(1)
+		}
+
+		config, err := utils.ReadConfigFile()
+		if err != nil {
+			fmt.Println("Failed to read config file:", err)
+			os.Exit(1)
+		}
+
+		var ok bool
+		query, ok = config.Grep.Queries[saved]
+		if !ok {
+			fmt.Println(color.RedString("No query named '%s' has been saved in the config file.", saved) + color.GreenString("\nRun: git-utils grep --list-saved"))
+			os.Exit(1)
+		}
+		if query.Pattern == "" {
+			fmt.Printf("The saved query '%s' has no pattern\n", saved)
+			os.Exit(1)
+		}
+		if err := query.ValidateFlags(); err != nil {
+			fmt.Printf("The saved query '%s' is invalid: %s\n", saved, err)
+			os.Exit(1)
+		}
+	} else {
+		if len(args) != 1 {
+			grepCmd.Help()
+			return
+		}
+		query.Pattern = args[0]
+	}
+	query.Include = append(query.Include, include...)
+	query.Exclude = append(query.Exclude, exclude...)
 
 	if dir == "" {
 		// Use current working directory if --dir flag is not specified
@@ -57,57 +116,182 @@ func runGrep(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	foundMatch := false
+	printer, ok := grepPrinters[format]
+	if !ok {
+		fmt.Printf("Unknown output format '%s'\n", format)
+		os.Exit(1)
+	}
 
-	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+	var matches []utils.GrepMatch
+	err := utils.WalkRepositories(dir, func(path string) error {
+		repoMatches, err := utils.GitGrep(path, query, context)
 		if err != nil {
 			return err
 		}
-		// Only process directories
-		if !d.IsDir() {
-			return nil
-		}
-		
-		// Check if the directory contains a .git subdirectory
-		gitDir := filepath.Join(path, ".git")
-		_, err = os.Stat(gitDir)
-		if os.IsNotExist(err) {
-			return nil
-		}
+		matches = append(matches, repoMatches...)
+		return nil
+	})
 
-		// Execute git grep in the git repository
-		cmd := exec.Command("git", "grep", "-n", pattern)
-		cmd.Dir = path
-		output, err := cmd.Output()
-		if err != nil {
-			// Ignore "exit status 1" error when no matches are found
-			if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-				return nil
-			}
-			return err
-		}
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	err = printer(matches)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+}
+
+func listSavedGrepQueries() {
+	config, err := utils.ReadConfigFile()
+	if err != nil {
+		fmt.Println("Failed to read config file:", err)
+		os.Exit(1)
+	}
+
+	if len(config.Grep.Queries) == 0 {
+		fmt.Println("No queries have been saved in the config file.\nRun: git-utils --config")
+		return
+	}
+
+	names := make([]string, 0, len(config.Grep.Queries))
+	for name := range config.Grep.Queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPATTERN\tFLAGS\tINCLUDE\tEXCLUDE")
+	for _, name := range names {
+		query := config.Grep.Queries[name]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name, query.Pattern,
+			strings.Join(query.Flags, " "), strings.Join(query.Include, ","), strings.Join(query.Exclude, ","))
+	}
+	w.Flush()
+}
+
+var grepPrinters = map[string]func(matches []utils.GrepMatch) error{
+	"text":    printGrepText,
+	"json":    printGrepJSON,
+	"vimgrep": printGrepVimgrep,
+	"summary": printGrepSummary,
+	"sarif":   printGrepSarif,
+}
 
-		lines := strings.Split(string(output), "\n")
-		for _, line := range lines {
-			if line != "" {
-				foundMatch = true
-				parts := strings.SplitN(line, ":", 2)
-				if len(parts) == 2 {
-					coloredLine := strings.ReplaceAll(parts[1], pattern, color.RedString(pattern))
-					fmt.Printf("\n%s:\nL%s\n", filepath.Join(path, parts[0]), coloredLine)
-				}
+func printGrepText(matches []utils.GrepMatch) error {
+	if len(matches) == 0 {
+		fmt.Println("No matches found.")
+		return nil
+	}
+
+	// git grep reports every occurrence, print each line only once with all
+	// of its occurrences highlighted
+	for i := 0; i < len(matches); {
+		j := i
+		coloredLine := matches[i].Text
+		seen := make(map[string]bool)
+		for ; j < len(matches) && matches[j].Path() == matches[i].Path() && matches[j].Line == matches[i].Line; j++ {
+			if !seen[matches[j].Match] {
+				seen[matches[j].Match] = true
+				coloredLine = strings.ReplaceAll(coloredLine, matches[j].Match, color.RedString(matches[j].Match))
 			}
 		}
+		fmt.Printf("\n%s:\nL%d:%s\n", matches[i].Path(), matches[i].Line, coloredLine)
+		i = j
+	}
 
-		return nil
-	})
+	return nil
+}
 
+func printGrepJSON(matches []utils.GrepMatch) error {
+	if matches == nil {
+		matches = []utils.GrepMatch{}
+	}
+
+	data, err := json.MarshalIndent(matches, "", "    ")
 	if err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(1)
+		return err
+	}
+
+	fmt.Println(string(data))
+	return nil
+}
+
+func printGrepVimgrep(matches []utils.GrepMatch) error {
+	for _, match := range matches {
+		fmt.Printf("%s:%d:%d:%s\n", match.Path(), match.Line, match.Column, match.Text)
 	}
+	return nil
+}
 
-	if !foundMatch {
+func printGrepSummary(matches []utils.GrepMatch) error {
+	if len(matches) == 0 {
 		fmt.Println("No matches found.")
+		return nil
 	}
+
+	var repos []string
+	counts := make(map[string]int)
+	files := make(map[string]map[string]bool)
+	for _, match := range matches {
+		if _, ok := counts[match.Repo]; !ok {
+			repos = append(repos, match.Repo)
+			files[match.Repo] = make(map[string]bool)
+		}
+		counts[match.Repo]++
+		files[match.Repo][match.File] = true
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tFILES\tMATCHES")
+	for _, repo := range repos {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", repo, len(files[repo]), counts[repo])
+	}
+	fmt.Fprintf(w, "Total\t\t%d\n", len(matches))
+	return w.Flush()
+}
+
+func printGrepSarif(matches []utils.GrepMatch) error {
+	results := []map[string]interface{}{}
+	for _, match := range matches {
+		results = append(results, map[string]interface{}{
+			"ruleId":  "git-utils-grep",
+			"level":   "note",
+			"message": map[string]string{"text": match.Text},
+			"locations": []map[string]interface{}{{
+				"physicalLocation": map[string]interface{}{
+					"artifactLocation": map[string]string{"uri": match.Path()},
+					"region": map[string]interface{}{
+						"startLine":   match.Line,
+						"startColumn": match.Column,
+						"snippet":     map[string]string{"text": match.Match},
+					},
+				},
+			}},
+		})
+	}
+
+	report := map[string]interface{}{
+		"version": "2.1.0",
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"runs": []map[string]interface{}{{
+			"tool": map[string]interface{}{
+				"driver": map[string]string{
+					"name":           "git-utils",
+					"informationUri": "https://github.com/arzkar/git-utils",
+				},
+			},
+			"results": results,
+		}},
+	}
+
+	data, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(data))
+	return nil
 }
@@ -0,0 +1,142 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/arzkar/git-utils/utils"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var replaceCmd *cobra.Command
+
+func init() {
+	replaceCmd = &cobra.Command{
+		Use:   "replace <pattern> <replacement>",
+		Short: "Search and replace a pattern in files",
+		Long: `Recursively search and replace a pattern in the tracked files of all the repositories within the specified directory.
+The pattern is a regular expression and the replacement can reference capture groups as $1 or ${name}.
+Without --apply only a preview of the changes is shown.`,
+		Args: cobra.ExactArgs(2),
+		Run:  runReplace,
+	}
+
+	replaceCmd.Flags().StringP("dir", "d", "", "Directory to perform the replace operation")
+	replaceCmd.Flags().BoolP("fixed-strings", "F", false, "Treat the pattern and replacement as literal strings")
+	replaceCmd.Flags().StringSlice("include", nil, "Only replace in files matching these pathspecs (e.g. '*.go')")
+	replaceCmd.Flags().StringSlice("exclude", nil, "Skip files matching these pathspecs")
+	replaceCmd.Flags().Bool("apply", false, "Write the changes to the files")
+	replaceCmd.Flags().Bool("stage", false, "Stage the changed files in each repository (requires --apply)")
+
+	rootCmd.AddCommand(replaceCmd)
+}
+
+func runReplace(cmd *cobra.Command, args []string) {
+	pattern, replacement := args[0], args[1]
+	dir, _ := cmd.Flags().GetString("dir")
+	fixedStrings, _ := cmd.Flags().GetBool("fixed-strings")
+	include, _ := cmd.Flags().GetStringSlice("include")
+	exclude, _ := cmd.Flags().GetStringSlice("exclude")
+	apply, _ := cmd.Flags().GetBool("apply")
+	stage, _ := cmd.Flags().GetBool("stage")
+
+	if dir == "" {
+		// Use current working directory if --dir flag is not specified
+		dir, _ = os.Getwd()
+	} else {
+		_, err := os.Stat(dir)
+		if os.IsNotExist(err) {
+			fmt.Printf("Directory '%s' does not exist\n", dir)
+			os.Exit(1)
+		}
+	}
+
+	if stage && !apply {
+		fmt.Println("The --stage flag requires --apply")
+		os.Exit(1)
+	}
+
+	if fixedStrings {
+		pattern = regexp.QuoteMeta(pattern)
+		replacement = strings.ReplaceAll(replacement, "$", "$$")
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		fmt.Println("Invalid pattern:", err)
+		os.Exit(1)
+	}
+
+	totalFiles, totalCount := 0, 0
+	err = utils.WalkRepositories(dir, func(path string) error {
+		replacements, err := utils.FindReplacements(path, re, replacement, include, exclude)
+		if err != nil {
+			return err
+		}
+		if len(replacements) == 0 {
+			return nil
+		}
+
+		var files []string
+		for _, r := range replacements {
+			fmt.Printf("\n%s: %d replacement(s)\n", r.Path(), r.Count)
+			fmt.Print(utils.ColorizeDiff(r.Diff()))
+
+			if apply {
+				err := r.Apply()
+				if err != nil {
+					return fmt.Errorf("failed to write file '%s': %w", r.Path(), err)
+				}
+			}
+
+			files = append(files, r.File)
+			totalFiles++
+			totalCount += r.Count
+		}
+
+		if stage {
+			err := utils.StageFiles(path, files)
+			if err != nil {
+				return err
+			}
+			fmt.Printf(color.GreenString("Staged %d file(s) in repository '%s'\n", len(files), path))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	if totalCount == 0 {
+		fmt.Println("No matches found.")
+		return
+	}
+
+	if apply {
+		fmt.Println(color.GreenString("\nReplaced %d occurrence(s) in %d file(s)", totalCount, totalFiles))
+	} else {
+		fmt.Println(color.YellowString("\n%d occurrence(s) in %d file(s) would be replaced. Run again with --apply to write the changes.", totalCount, totalFiles))
+	}
+}
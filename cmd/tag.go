@@ -19,7 +19,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"strings"
+	"path/filepath"
 
 	"github.com/arzkar/git-utils/utils"
 	"github.com/fatih/color"
@@ -35,10 +35,23 @@ func init() {
 		Run:   runTag,
 	}
 
-	var tagName, tagMessage, dir string
+	var tagName, tagMessage, dir, prevTagStrategy, tagPattern, next string
+	var all, skipExisting, sign, includePrerelease, push, preview, edit bool
+	var filters []string
 	tagCmd.Flags().StringVarP(&tagName, "tag_name", "a", "", "Name of the tag")
 	tagCmd.Flags().StringVarP(&tagMessage, "tag_message", "m", "", "Message for the tag")
 	tagCmd.Flags().StringVarP(&dir, "dir", "", "", "Git directory (optional)")
+	tagCmd.Flags().BoolVar(&all, "all", false, "Tag every repository found in the directory")
+	tagCmd.Flags().StringSliceVar(&filters, "filter", nil, "Only tag repositories whose directory name matches these glob patterns (with --all)")
+	tagCmd.Flags().BoolVar(&skipExisting, "skip-existing", false, "Skip repositories where the tag already exists (with --all)")
+	tagCmd.Flags().BoolVarP(&sign, "sign", "s", false, "Create a signed tag using git's configured signing format (GPG, SSH or X.509)")
+	tagCmd.Flags().StringVar(&prevTagStrategy, "prev-tag-strategy", utils.PreviousTagAncestry, "How the previous tag is found: 'ancestry' (nearest reachable tag) or 'semver' (highest lower version)")
+	tagCmd.Flags().StringVar(&tagPattern, "tag-pattern", "*", "Only consider tags matching this glob as the previous tag (e.g. 'service-a/v*')")
+	tagCmd.Flags().BoolVar(&includePrerelease, "include-prerelease", false, "Consider pre-release tags as the previous tag")
+	tagCmd.Flags().BoolVar(&push, "push", false, "Push the new tag to the remote")
+	tagCmd.Flags().BoolVar(&preview, "preview", false, "Print the rendered tag message without creating the tag")
+	tagCmd.Flags().BoolVar(&edit, "edit", false, "Edit the rendered tag message in the git editor before creating the tag")
+	tagCmd.Flags().StringVar(&next, "next", "", "Name the tag after the next version of the latest semver tag: major, minor, patch or auto (from Conventional Commits)")
 	rootCmd.AddCommand(tagCmd)
 }
 
@@ -46,14 +59,33 @@ func runTag(cmd *cobra.Command, args []string) {
 	tagName, _ := cmd.Flags().GetString("tag_name")
 	tagMessage, _ := cmd.Flags().GetString("tag_message")
 	dir, _ := cmd.Flags().GetString("dir")
+	all, _ := cmd.Flags().GetBool("all")
+	next, _ := cmd.Flags().GetString("next")
 
-	if tagName == "" || tagMessage == "" {
+	if (tagName == "" && next == "") || tagMessage == "" {
 		tagCmd.Help()
 		return
 	}
+	if tagName != "" && next != "" {
+		fmt.Println(color.RedString("Use either --tag_name or --next, not both"))
+		os.Exit(1)
+	}
+	switch next {
+	case "", "major", "minor", "patch", "auto":
+	default:
+		fmt.Println(color.RedString("Invalid --next value '%s', use major, minor, patch or auto", next))
+		os.Exit(1)
+	}
+
+	options := getTagOptions(cmd)
 
-	// Read the config file
-	config, err := utils.ReadConfigFile()
+	if all {
+		runTagAll(cmd, tagName, tagMessage, options)
+		return
+	}
+
+	// Read the app and repository config files
+	messages, err := utils.GetTagMessages(dir)
 	if err != nil {
 		fmt.Println("Failed to read config file:", err)
 		fmt.Println("Set the message values in the config file.\nRun: git-utils --config", err)
@@ -61,42 +93,287 @@ func runTag(cmd *cobra.Command, args []string) {
 	}
 
 	// Check if the tag message matches a configured message
-	message, ok := config.Tags.Messages[tagMessage]
+	message, ok := messages[tagMessage]
 	if !ok {
 		fmt.Println(color.RedString("No message has been set in the config file. Set it up before running the tag command.") + color.GreenString("\nRun: git-utils --config"))
 		return
 	}
 
-	prevTag, err := getPreviousTag(dir)
+	tagName, err = resolveTagName(dir, tagName, options)
 	if err != nil {
-		fmt.Println(color.RedString("Failed to get the previous tag:", err))
+		fmt.Println(color.RedString("Failed to compute the next tag: %s", err))
+		os.Exit(1)
+	}
+
+	if options.preview {
+		tagMessage, err := renderTagMessage(dir, tagName, message, options)
+		if err != nil {
+			fmt.Println(color.RedString("Failed to render the tag message: %s", err))
+			os.Exit(1)
+		}
+		fmt.Println(color.GreenString("Tag '%s':", tagName))
+		fmt.Println(tagMessage)
 		return
 	}
 
-	newTag := tagName
-	templateVariables := utils.CreateTemplateVariables(dir, prevTag, newTag, message)
-	tagMessage = utils.ParseTemplate(message, templateVariables)
+	err = createRepositoryTag(dir, tagName, message, options)
+	if err != nil {
+		fmt.Println(color.RedString("Failed to create tag: %s", err))
+		return
+	}
 
-	// Create the tag using the git command in the specified directory
-	cmdGit := exec.Command("git", "-C", dir, "tag", tagName, "-a", "-m", tagMessage)
-	cmdGit.Stdout = os.Stdout
-	cmdGit.Stderr = os.Stderr
-	err = cmdGit.Run()
+	if !options.push {
+		fmt.Println(color.GreenString("Tag '%s' created successfully. Push it by running: git-utils tag push %s", tagName, tagName))
+		return
+	}
+
+	err = utils.PushTag(dir, defaultRemote, tagName)
 	if err != nil {
-		fmt.Println(color.RedString("Failed to create tag:", err))
+		fmt.Println(color.RedString("Tag created but failed to push it: %s", err))
+		os.Exit(1)
+	}
+	fmt.Println(color.GreenString("Tag '%s' created and pushed successfully.", tagName))
+}
+
+func runTagAll(cmd *cobra.Command, tagName, tagMessage string, options tagOptions) {
+	dir, _ := cmd.Flags().GetString("dir")
+	filters, _ := cmd.Flags().GetStringSlice("filter")
+	skipExisting, _ := cmd.Flags().GetBool("skip-existing")
+
+	if dir == "" {
+		// Use current working directory if --dir flag is not specified
+		dir, _ = os.Getwd()
+	} else {
+		_, err := os.Stat(dir)
+		if os.IsNotExist(err) {
+			fmt.Printf("Directory '%s' does not exist\n", dir)
+			os.Exit(1)
+		}
+	}
+
+	var repos []string
+	err := utils.WalkRepositories(dir, func(path string) error {
+		matched, err := matchesRepositoryFilters(path, filters)
+		if err != nil {
+			return err
+		}
+		if matched {
+			repos = append(repos, path)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	if len(repos) == 0 {
+		fmt.Println("No repositories found.")
+		return
+	}
+
+	// Pre-flight: report the repositories where the tag already exists
+	var pending, existing []string
+	tagNames := make(map[string]string)
+	messages := make(map[string]string)
+	for _, repo := range repos {
+		repoMessages, err := utils.GetTagMessages(repo)
+		if err != nil {
+			fmt.Println(color.RedString("Failed to read config file for repository '%s': %s", repo, err))
+			os.Exit(1)
+		}
+		message, ok := repoMessages[tagMessage]
+		if !ok {
+			fmt.Println(color.RedString("No message has been set in the config file for repository '%s'. Set it up before running the tag command.", repo) + color.GreenString("\nRun: git-utils --config"))
+			os.Exit(1)
+		}
+		messages[repo] = message
+
+		name, err := resolveTagName(repo, tagName, options)
+		if err != nil {
+			fmt.Println(color.RedString("Failed to compute the next tag in repository '%s': %s", repo, err))
+			os.Exit(1)
+		}
+		tagNames[repo] = name
+
+		if tagExists(repo, name) {
+			existing = append(existing, repo)
+		} else {
+			pending = append(pending, repo)
+		}
+	}
+
+	if len(existing) > 0 {
+		fmt.Println(color.YellowString("The tag already exists in:"))
+		for _, repo := range existing {
+			fmt.Printf("  %s (%s)\n", repo, tagNames[repo])
+		}
+		if !skipExisting {
+			fmt.Println(color.RedString("Aborting. Run again with --skip-existing to tag the remaining repositories."))
+			os.Exit(1)
+		}
+		fmt.Println()
+	}
+
+	failed := 0
+	for _, repo := range pending {
+		tagName := tagNames[repo]
+
+		if options.preview {
+			tagMessage, err := renderTagMessage(repo, tagName, messages[repo], options)
+			if err != nil {
+				fmt.Println(color.RedString("Failed to render the tag message in repository '%s': %s\n", repo, err))
+				failed++
+				continue
+			}
+			fmt.Println(color.GreenString("Tag '%s' in repository '%s':", tagName, repo))
+			fmt.Println(tagMessage + "\n")
+			continue
+		}
+
+		fmt.Printf("Creating tag '%s' in repository '%s'\n", tagName, repo)
+		err := createRepositoryTag(repo, tagName, messages[repo], options)
+		if err != nil {
+			fmt.Println(color.RedString("Failed to create tag in repository '%s': %s\n", repo, err))
+			failed++
+			continue
+		}
+		fmt.Println(color.GreenString("Successfully created tag '%s' in repository '%s'", tagName, repo))
+
+		if options.push {
+			err := utils.PushTag(repo, defaultRemote, tagName)
+			if err != nil {
+				fmt.Println(color.RedString("Failed to push tag: %s\n", err))
+				failed++
+				continue
+			}
+			fmt.Println(color.GreenString("Successfully pushed tag '%s' in repository '%s'", tagName, repo))
+		}
+		fmt.Println()
+	}
+
+	if failed > 0 {
+		fmt.Println(color.RedString("Failed to create or push the tag in %d of %d repositories", failed, len(pending)))
+		os.Exit(1)
+	}
+
+	if options.preview {
 		return
 	}
 
-	fmt.Println(color.GreenString("Tag created successfully. Push it by running: git push --tags"))
+	if options.push {
+		fmt.Println(color.GreenString("Tags created and pushed successfully."))
+		return
+	}
+	if options.next != "" {
+		fmt.Println(color.GreenString("Tags created successfully. Push them by running: git-utils tag push <tag>"))
+		return
+	}
+	fmt.Println(color.GreenString("Tags created successfully. Push them by running: git-utils tag push %s", tagName))
+}
+
+type tagOptions struct {
+	sign        bool
+	push        bool
+	preview     bool
+	edit        bool
+	next        string
+	previousTag utils.PreviousTagOptions
+}
+
+func getTagOptions(cmd *cobra.Command) tagOptions {
+	sign, _ := cmd.Flags().GetBool("sign")
+	push, _ := cmd.Flags().GetBool("push")
+	preview, _ := cmd.Flags().GetBool("preview")
+	edit, _ := cmd.Flags().GetBool("edit")
+	next, _ := cmd.Flags().GetString("next")
+	strategy, _ := cmd.Flags().GetString("prev-tag-strategy")
+	pattern, _ := cmd.Flags().GetString("tag-pattern")
+	includePrerelease, _ := cmd.Flags().GetBool("include-prerelease")
+
+	return tagOptions{
+		sign:    sign,
+		push:    push,
+		preview: preview,
+		edit:    edit,
+		next:    next,
+		previousTag: utils.PreviousTagOptions{
+			Strategy:          strategy,
+			Pattern:           pattern,
+			IncludePrerelease: includePrerelease,
+		},
+	}
+}
+
+// resolveTagName returns the tag name to use in the repository, computing
+// the next version when --next is used
+func resolveTagName(dir, tagName string, options tagOptions) (string, error) {
+	if options.next == "" {
+		return tagName, nil
+	}
+	return utils.GetNextTag(dir, options.next, options.previousTag)
 }
 
-func getPreviousTag(dir string) (string, error) {
-	cmdGit := exec.Command("git", "-C", dir, "describe", "--abbrev=0", "--tags", "--exclude=*-*")
-	output, err := cmdGit.Output()
+// renderTagMessage renders the message template for the repository in dir
+func renderTagMessage(dir, tagName, message string, options tagOptions) (string, error) {
+	prevTag, err := utils.GetPreviousTag(dir, tagName, options.previousTag)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the previous tag: %w", err)
+	}
+
+	newTag := tagName
+	templateVariables, err := utils.CreateTemplateVariables(dir, prevTag, newTag, message)
 	if err != nil {
 		return "", err
 	}
+	return utils.ParseTemplate(message, templateVariables)
+}
+
+// createRepositoryTag renders the message template for the repository in
+// dir and creates the annotated (or signed) tag
+func createRepositoryTag(dir, tagName, message string, options tagOptions) error {
+	tagMessage, err := renderTagMessage(dir, tagName, message, options)
+	if err != nil {
+		return err
+	}
+
+	// Create the tag using the git command in the specified directory
+	tagType := "-a"
+	if options.sign {
+		tagType = "-s"
+	}
+	args := []string{"-C", dir, "tag", tagName, tagType, "-m", tagMessage}
+	if options.edit {
+		// git opens the message in $GIT_EDITOR, core.editor or $EDITOR
+		args = append(args, "--edit")
+	}
+	cmdGit := exec.Command("git", args...)
+	cmdGit.Stdin = os.Stdin
+	cmdGit.Stdout = os.Stdout
+	cmdGit.Stderr = os.Stderr
+	return cmdGit.Run()
+}
+
+func matchesRepositoryFilters(path string, filters []string) (bool, error) {
+	if len(filters) == 0 {
+		return true, nil
+	}
+
+	name := filepath.Base(path)
+	for _, filter := range filters {
+		matched, err := filepath.Match(filter, name)
+		if err != nil {
+			return false, fmt.Errorf("invalid filter '%s': %w", filter, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
 
-	prevTag := strings.TrimSpace(string(output))
-	return prevTag, nil
+func tagExists(dir, tagName string) bool {
+	cmdGit := exec.Command("git", "-C", dir, "rev-parse", "-q", "--verify", "refs/tags/"+tagName)
+	return cmdGit.Run() == nil
 }
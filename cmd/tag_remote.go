@@ -0,0 +1,252 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/arzkar/git-utils/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+const defaultRemote = "origin"
+
+var tagListCmd, tagDeleteCmd, tagPushCmd *cobra.Command
+
+func init() {
+	tagListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the tags of all repositories",
+		Long:  "List the tags of all repositories, or only the local tags missing from the remote",
+		Args:  cobra.NoArgs,
+		Run:   runTagList,
+	}
+
+	tagDeleteCmd = &cobra.Command{
+		Use:   "delete <tag>",
+		Short: "Delete a tag locally and on the remote in all repositories",
+		Args:  cobra.ExactArgs(1),
+		Run:   runTagDelete,
+	}
+
+	tagPushCmd = &cobra.Command{
+		Use:   "push <tag>",
+		Short: "Push a tag to the remote in all repositories",
+		Args:  cobra.ExactArgs(1),
+		Run:   runTagPush,
+	}
+
+	for _, cmd := range []*cobra.Command{tagListCmd, tagDeleteCmd, tagPushCmd} {
+		cmd.Flags().StringP("dir", "d", "", "Directory to perform the operation")
+		tagCmd.AddCommand(cmd)
+	}
+	tagListCmd.Flags().Bool("missing", false, "Only list local tags that are missing from the remote")
+	tagDeleteCmd.Flags().Bool("local-only", false, "Only delete the local tag")
+	tagDeleteCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+}
+
+func getTagCommandDir(cmd *cobra.Command) string {
+	dir, _ := cmd.Flags().GetString("dir")
+
+	if dir == "" {
+		// Use current working directory if --dir flag is not specified
+		dir, _ = os.Getwd()
+	} else {
+		_, err := os.Stat(dir)
+		if os.IsNotExist(err) {
+			fmt.Printf("Directory '%s' does not exist\n", dir)
+			os.Exit(1)
+		}
+	}
+
+	return dir
+}
+
+func runTagList(cmd *cobra.Command, args []string) {
+	dir := getTagCommandDir(cmd)
+	missing, _ := cmd.Flags().GetBool("missing")
+
+	err := utils.WalkRepositories(dir, func(path string) error {
+		tags, err := utils.GetLocalTags(path)
+		if err != nil {
+			return err
+		}
+
+		if missing {
+			remoteTags, err := utils.GetRemoteTags(path, defaultRemote)
+			if err != nil {
+				fmt.Println(color.RedString("Error: %s", err))
+				return nil
+			}
+
+			var missingTags []string
+			for _, tag := range tags {
+				if !remoteTags[tag] {
+					missingTags = append(missingTags, tag)
+				}
+			}
+			tags = missingTags
+		}
+
+		if len(tags) == 0 {
+			return nil
+		}
+
+		fmt.Println(color.GreenString("%s:", path))
+		for _, tag := range tags {
+			fmt.Println("  " + tag)
+		}
+		return nil
+	})
+
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+}
+
+func runTagDelete(cmd *cobra.Command, args []string) {
+	tag := args[0]
+	dir := getTagCommandDir(cmd)
+	localOnly, _ := cmd.Flags().GetBool("local-only")
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	type tagLocation struct {
+		repo   string
+		local  bool
+		remote bool
+	}
+
+	var locations []tagLocation
+	err := utils.WalkRepositories(dir, func(path string) error {
+		location := tagLocation{repo: path, local: tagExists(path, tag)}
+		if !localOnly {
+			remoteTags, err := utils.GetRemoteTags(path, defaultRemote)
+			if err != nil {
+				fmt.Println(color.RedString("Error: %s", err))
+			} else {
+				location.remote = remoteTags[tag]
+			}
+		}
+		if location.local || location.remote {
+			locations = append(locations, location)
+		}
+		return nil
+	})
+
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	if len(locations) == 0 {
+		fmt.Printf("Tag '%s' was not found in any repository\n", tag)
+		return
+	}
+
+	fmt.Printf("Tag '%s' will be deleted in:\n", tag)
+	for _, location := range locations {
+		var where []string
+		if location.local {
+			where = append(where, "local")
+		}
+		if location.remote {
+			where = append(where, defaultRemote)
+		}
+		fmt.Printf("  %s (%s)\n", location.repo, strings.Join(where, ", "))
+	}
+
+	if !yes && !confirm("Delete the tag?") {
+		fmt.Println("Aborted.")
+		return
+	}
+
+	failed := false
+	for _, location := range locations {
+		if location.remote {
+			err := utils.DeleteRemoteTag(location.repo, defaultRemote, tag)
+			if err != nil {
+				fmt.Println(color.RedString("Error: %s", err))
+				failed = true
+				continue
+			}
+		}
+		if location.local {
+			err := utils.DeleteLocalTag(location.repo, tag)
+			if err != nil {
+				fmt.Println(color.RedString("Error: %s", err))
+				failed = true
+				continue
+			}
+		}
+		fmt.Println(color.GreenString("Successfully deleted tag '%s' in repository '%s'", tag, location.repo))
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func runTagPush(cmd *cobra.Command, args []string) {
+	tag := args[0]
+	dir := getTagCommandDir(cmd)
+
+	found, failed := false, false
+	err := utils.WalkRepositories(dir, func(path string) error {
+		if !tagExists(path, tag) {
+			return nil
+		}
+		found = true
+
+		fmt.Printf("Pushing tag '%s' in repository '%s'\n", tag, path)
+		err := utils.PushTag(path, defaultRemote, tag)
+		if err != nil {
+			fmt.Println(color.RedString("Error: %s", err))
+			failed = true
+			return nil
+		}
+		fmt.Println(color.GreenString("Successfully pushed tag '%s' in repository '%s'\n", tag, path))
+		return nil
+	})
+
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	if !found {
+		fmt.Printf("Tag '%s' was not found in any repository\n", tag)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to no
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/arzkar/git-utils/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var tagVerifyCmd *cobra.Command
+
+// Markers of the signature formats git can use for tags
+var signatureMarkers = []string{
+	"-----BEGIN PGP SIGNATURE-----",
+	"-----BEGIN SSH SIGNATURE-----",
+	"-----BEGIN SIGNED MESSAGE-----",
+}
+
+func init() {
+	tagVerifyCmd = &cobra.Command{
+		Use:   "verify [tag]",
+		Short: "Verify tag signatures in all repositories",
+		Long:  "Verify the signature of a tag, or of every tag if none is given, in all repositories and report unsigned or invalid ones",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   runTagVerify,
+	}
+
+	tagVerifyCmd.Flags().StringP("dir", "d", "", "Directory to perform the verify operation")
+
+	tagCmd.AddCommand(tagVerifyCmd)
+}
+
+func runTagVerify(cmd *cobra.Command, args []string) {
+	dir, _ := cmd.Flags().GetString("dir")
+
+	if dir == "" {
+		// Use current working directory if --dir flag is not specified
+		dir, _ = os.Getwd()
+	} else {
+		_, err := os.Stat(dir)
+		if os.IsNotExist(err) {
+			fmt.Printf("Directory '%s' does not exist\n", dir)
+			os.Exit(1)
+		}
+	}
+
+	verified, unsigned, invalid := 0, 0, 0
+	err := utils.WalkRepositories(dir, func(path string) error {
+		var tags []string
+		if len(args) == 1 {
+			if !tagExists(path, args[0]) {
+				fmt.Printf("Tag '%s' does not exist in repository '%s'\n", args[0], path)
+				return nil
+			}
+			tags = []string{args[0]}
+		} else {
+			output, err := exec.Command("git", "-C", path, "tag", "--list").Output()
+			if err != nil {
+				return fmt.Errorf("failed to list tags in repository '%s': %w", path, err)
+			}
+			tags = strings.Fields(string(output))
+		}
+
+		for _, tag := range tags {
+			switch status, details := verifyTag(path, tag); status {
+			case "verified":
+				verified++
+				fmt.Println(color.GreenString("Verified: tag '%s' in repository '%s'", tag, path))
+			case "unsigned":
+				unsigned++
+				fmt.Println(color.YellowString("Unsigned: tag '%s' in repository '%s'", tag, path))
+			default:
+				invalid++
+				fmt.Println(color.RedString("Invalid: tag '%s' in repository '%s'\n%s", tag, path, details))
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n%d verified, %d unsigned, %d invalid\n", verified, unsigned, invalid)
+	if unsigned > 0 || invalid > 0 {
+		os.Exit(1)
+	}
+}
+
+// verifyTag returns "verified", "unsigned" or "invalid" for the tag along
+// with git's output when the signature is invalid
+func verifyTag(dir, tag string) (string, string) {
+	// Lightweight tags point directly to a commit and can't be signed
+	output, err := exec.Command("git", "-C", dir, "cat-file", "-t", "refs/tags/"+tag).Output()
+	if err != nil || strings.TrimSpace(string(output)) != "tag" {
+		return "unsigned", ""
+	}
+
+	output, err = exec.Command("git", "-C", dir, "verify-tag", tag).CombinedOutput()
+	if err == nil {
+		return "verified", ""
+	}
+
+	content, err := exec.Command("git", "-C", dir, "cat-file", "tag", "refs/tags/"+tag).Output()
+	if err != nil {
+		return "invalid", err.Error()
+	}
+	for _, marker := range signatureMarkers {
+		if strings.Contains(string(content), marker) {
+			return "invalid", strings.TrimSpace(string(output))
+		}
+	}
+
+	return "unsigned", ""
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package giturl parses git remote URLs in all the forms git accepts.
+package giturl
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type URL struct {
+	// Scheme is "ssh", "https", "http", "git" or "file". scp-like remotes
+	// (git@host:owner/repo.git) use "ssh" and local paths use "file".
+	Scheme string
+	// User is the user name, any password or token is dropped
+	User string
+	Host string
+	Port string
+	// Path is the repository path without the leading slash or .git suffix.
+	// Azure DevOps paths are normalized to org/project/repo, without the v3/
+	// prefix of ssh remotes or the _git segment of https remotes.
+	Path string
+	// Namespace is everything in Path before the name, e.g. the GitLab
+	// group and subgroups
+	Namespace string
+	// Owner is the first segment of Namespace
+	Owner string
+	Name  string
+}
+
+// Parse parses a remote URL in one of the following forms:
+//
+//	[user@]host:path/to/repo.git
+//	ssh://[user@]host[:port]/path/to/repo.git
+//	http[s]://[user[:password]@]host[:port]/path/to/repo.git
+//	git://host[:port]/path/to/repo.git
+//	file:///path/to/repo.git
+//	/path/to/repo.git
+func Parse(rawURL string) (*URL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return nil, fmt.Errorf("empty remote URL")
+	}
+
+	var u *URL
+	var err error
+	switch {
+	case strings.Contains(rawURL, "://"):
+		u, err = parseURL(rawURL)
+	case isSCPLike(rawURL):
+		u, err = parseSCPLike(rawURL)
+	default:
+		u = &URL{Scheme: "file", Path: strings.ReplaceAll(rawURL, "\\", "/")}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "file" {
+		u.Path = strings.TrimRight(u.Path, "/")
+	} else {
+		u.Path = strings.Trim(u.Path, "/")
+		if u.Host == "" {
+			return nil, fmt.Errorf("missing host in remote URL: %s", rawURL)
+		}
+	}
+	u.Path = strings.TrimSuffix(u.Path, ".git")
+	if u.Scheme != "file" && isAzureHost(u.Host) {
+		u.Path = trimAzurePath(u.Path)
+	}
+
+	segments := strings.Split(strings.TrimLeft(u.Path, "/"), "/")
+	u.Name = segments[len(segments)-1]
+	if u.Name == "" || u.Name == "." || u.Name == ".." {
+		return nil, fmt.Errorf("missing repository name in remote URL: %s", rawURL)
+	}
+	if len(segments) > 1 {
+		u.Namespace = strings.Join(segments[:len(segments)-1], "/")
+		u.Owner = segments[0]
+	}
+
+	return u, nil
+}
+
+func parseURL(rawURL string) (*URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote URL: %w", err)
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	switch scheme {
+	case "ssh", "git+ssh", "ssh+git":
+		scheme = "ssh"
+	case "http", "https", "git", "file":
+	default:
+		return nil, fmt.Errorf("unsupported scheme '%s' in remote URL: %s", parsed.Scheme, rawURL)
+	}
+
+	u := &URL{
+		Scheme: scheme,
+		Host:   parsed.Hostname(),
+		Port:   parsed.Port(),
+		Path:   parsed.Path,
+	}
+	if parsed.User != nil {
+		u.User = parsed.User.Username()
+	}
+
+	// ssh://host/~user/repo.git is relative to the user's home directory
+	u.Path = strings.TrimPrefix(u.Path, "/~")
+
+	return u, nil
+}
+
+func parseSCPLike(rawURL string) (*URL, error) {
+	var userHost, path string
+	if strings.HasPrefix(rawURL, "[") {
+		// [user@host:port]:path is also accepted by git
+		end := strings.Index(rawURL, "]:")
+		if end == -1 {
+			return nil, fmt.Errorf("invalid remote URL: %s", rawURL)
+		}
+		userHost, path = rawURL[1:end], rawURL[end+2:]
+	} else {
+		i := strings.Index(rawURL, ":")
+		userHost, path = rawURL[:i], rawURL[i+1:]
+	}
+
+	u := &URL{Scheme: "ssh", Host: userHost, Path: path}
+	if at := strings.LastIndex(u.Host, "@"); at != -1 {
+		u.User, u.Host = u.Host[:at], u.Host[at+1:]
+	}
+	if host, port, ok := strings.Cut(u.Host, ":"); ok {
+		u.Host, u.Port = host, port
+	}
+
+	return u, nil
+}
+
+// isAzureHost reports whether the host is Azure DevOps or its older
+// visualstudio.com domain
+func isAzureHost(host string) bool {
+	host = strings.ToLower(host)
+	return host == "dev.azure.com" || strings.HasSuffix(host, ".dev.azure.com") || strings.HasSuffix(host, ".visualstudio.com")
+}
+
+// trimAzurePath removes the v3/ prefix of Azure DevOps ssh remotes
+// (v3/org/project/repo) and the _git segment of https remotes
+// (org/project/_git/repo)
+func trimAzurePath(path string) string {
+	path = strings.TrimPrefix(path, "v3/")
+	segments := strings.Split(path, "/")
+	if len(segments) > 2 && segments[len(segments)-2] == "_git" {
+		segments = append(segments[:len(segments)-2], segments[len(segments)-1])
+	}
+	return strings.Join(segments, "/")
+}
+
+// isSCPLike reports whether the remote uses the scp-like syntax, which git
+// recognizes by a colon before the first slash
+func isSCPLike(rawURL string) bool {
+	colon := strings.Index(rawURL, ":")
+	if colon <= 0 {
+		return false
+	}
+
+	// Windows drive letters (C:\repo or C:/repo) are local paths
+	if colon == 1 && len(rawURL) > 2 && (rawURL[2] == '\\' || rawURL[2] == '/') {
+		return false
+	}
+
+	slash := strings.IndexAny(rawURL, "/\\")
+	return slash == -1 || colon < slash
+}
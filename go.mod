@@ -4,11 +4,11 @@ go 1.20
 
 require (
 	github.com/fatih/color v1.15.0
+	github.com/go-ini/ini v1.67.0
 	github.com/spf13/cobra v1.7.0
 )
 
 require (
-	github.com/go-ini/ini v1.67.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.17 // indirect
@@ -0,0 +1,402 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-ini/ini"
+)
+
+// fileSectionRegex matches [bumpversion:file:<path>] and
+// [bumpversion:glob:<pattern>] sections, and the
+// [bumpversion:file(<label>):<path>] form used to have several search and
+// replace pairs for the same file
+var fileSectionRegex = regexp.MustCompile(`^bumpversion:(file|glob)(?:\(([^)]*)\))?:(.+)$`)
+
+// placeholderRegex matches {name} and {name:format} placeholders
+var placeholderRegex = regexp.MustCompile(`\{(\w+)(?::([^{}]*))?\}`)
+
+// FileTarget is a search and replace pair of a file section, with its
+// placeholders already replaced
+type FileTarget struct {
+	Path    string
+	Label   string
+	Search  string
+	Replace string
+	// Key limits the search to the value of a key of a structured file
+	Key    string
+	Format string
+	// regex is set when the section has regex = True
+	regex *regexp.Regexp
+}
+
+// Check returns an error if the search pattern isn't found in the data
+func (t FileTarget) Check(data string) error {
+	if t.Key != "" {
+		start, end, err := locateValue(t.Format, data, t.Key)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t, err)
+		}
+		data = data[start:end]
+	}
+
+	if !t.matches(data) {
+		return fmt.Errorf("search pattern not found in file: %s", t)
+	}
+	return nil
+}
+
+// Apply replaces every match of the search pattern in the data, or in the
+// value of the key
+func (t FileTarget) Apply(data string) (string, error) {
+	if t.Key == "" {
+		return t.replace(data), nil
+	}
+
+	start, end, err := locateValue(t.Format, data, t.Key)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", t, err)
+	}
+	return data[:start] + t.replace(data[start:end]) + data[end:], nil
+}
+
+func (t FileTarget) matches(data string) bool {
+	if t.regex != nil {
+		return t.regex.MatchString(data)
+	}
+	return strings.Contains(data, t.Search)
+}
+
+func (t FileTarget) replace(data string) string {
+	if t.regex != nil {
+		return t.regex.ReplaceAllString(data, t.Replace)
+	}
+	return strings.ReplaceAll(data, t.Search, t.Replace)
+}
+
+func (t FileTarget) String() string {
+	name := t.Path
+	if t.Label != "" {
+		name += " (" + t.Label + ")"
+	}
+	if t.Key != "" {
+		name += " key " + t.Key
+	}
+	return name
+}
+
+// getFileTargets returns the search and replace pairs of the file and glob
+// sections for the version bump. The search pattern defaults to
+// {current_version} and the replacement to {new_version}.
+func getFileTargets(config *ini.File, currentVersion, newVersion string) ([]FileTarget, error) {
+	searchValues, replaceValues := bumpPlaceholders(currentVersion, newVersion)
+	now := time.Now()
+
+	var targets []FileTarget
+	for _, section := range config.Sections() {
+		matches := fileSectionRegex.FindStringSubmatch(section.Name())
+		if matches == nil {
+			continue
+		}
+
+		search := "{current_version}"
+		if section.HasKey("search") {
+			search = multilineValue(section.Key("search").String())
+		}
+		replace := "{new_version}"
+		if section.HasKey("replace") {
+			replace = multilineValue(section.Key("replace").String())
+		}
+		useRegex := false
+		if section.HasKey("regex") {
+			var err error
+			useRegex, err = section.Key("regex").Bool()
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex value in [%s]: %w", section.Name(), err)
+			}
+		}
+
+		target := FileTarget{
+			Label:   matches[2],
+			Search:  expandPlaceholders(search, searchValues, now, useRegex),
+			Replace: expandPlaceholders(replace, replaceValues, now, false),
+			Key:     section.Key("key").String(),
+			Format:  section.Key("format").String(),
+		}
+		if target.Search == "" {
+			return nil, fmt.Errorf("empty search pattern in [%s]", section.Name())
+		}
+		if useRegex {
+			// (?m) lets ^ and $ match at line boundaries in multi-line files
+			regex, err := regexp.Compile("(?m)" + target.Search)
+			if err != nil {
+				return nil, fmt.Errorf("invalid search regex in [%s]: %w", section.Name(), err)
+			}
+			target.regex = regex
+		}
+
+		paths := []string{matches[3]}
+		if matches[1] == "glob" {
+			var err error
+			paths, err = globFiles(matches[3])
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, path := range paths {
+			target.Path = path
+			if target.Key != "" {
+				format, err := structuredFormat(path, target.Format)
+				if err != nil {
+					return nil, fmt.Errorf("[%s]: %w", section.Name(), err)
+				}
+				target.Format = format
+			}
+			targets = append(targets, target)
+		}
+	}
+
+	return targets, nil
+}
+
+// targetPaths returns the files changed by the bump, including the config
+func targetPaths(currentVersion, newVersion string) ([]string, error) {
+	config, err := loadBumpConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := getFileTargets(config, currentVersion, newVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{bump_cfg}
+	for _, target := range targets {
+		paths = append(paths, target.Path)
+	}
+	return paths, nil
+}
+
+// globFiles returns the tracked files matching the glob pattern, where **
+// matches any number of directories. Untracked files aren't matched, as the
+// bump refuses to change them.
+func globFiles(pattern string) ([]string, error) {
+	cmd := exec.Command("git", "ls-files", "-z", "--cached", "--", ":(glob)"+pattern)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files matching '%s': %w", pattern, err)
+	}
+
+	var files []string
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file != "" && indexOf(files, file) == -1 {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no tracked files match the glob pattern: %s", pattern)
+	}
+	return files, nil
+}
+
+// bumpPlaceholders returns the placeholder values of the search patterns and
+// of the replacements. {current_<part>} and {new_<part>} are available in
+// both, while {<part>} is the part of the current version when searching and
+// of the new version when replacing.
+func bumpPlaceholders(currentVersion, newVersion string) (map[string]string, map[string]string) {
+	common := map[string]string{
+		"current_version": currentVersion,
+		"new_version":     newVersion,
+	}
+	currentParts := versionParts(currentVersion)
+	newParts := versionParts(newVersion)
+	for name, value := range currentParts {
+		common["current_"+name] = value
+	}
+	for name, value := range newParts {
+		common["new_"+name] = value
+	}
+
+	searchValues := make(map[string]string)
+	replaceValues := make(map[string]string)
+	for name, value := range common {
+		searchValues[name] = value
+		replaceValues[name] = value
+	}
+	for name, value := range currentParts {
+		searchValues[name] = value
+	}
+	for name, value := range newParts {
+		replaceValues[name] = value
+	}
+	return searchValues, replaceValues
+}
+
+// versionParts returns the parts of the version with the custom version
+// scheme, or the semantic version parts. Versions that can't be parsed, such
+// as calendar versions, have no parts.
+func versionParts(version string) map[string]string {
+	scheme, err := getVersionScheme()
+	if err != nil {
+		return nil
+	}
+	if scheme != nil {
+		parts, err := scheme.Parse(version)
+		if err != nil {
+			return nil
+		}
+		return parts
+	}
+
+	v, err := ParseVersion(strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return nil
+	}
+	return map[string]string{
+		"major":      fmt.Sprint(v.Major),
+		"minor":      fmt.Sprint(v.Minor),
+		"patch":      fmt.Sprint(v.Patch),
+		"prerelease": strings.Join(v.Prerelease, "."),
+		"build":      strings.Join(v.Build, "."),
+	}
+}
+
+// expandPlaceholders replaces the placeholders of the pattern with their
+// values, quoted when the pattern is a regex. {now:<format>} and
+// {utcnow:<format>} are the current time formatted with strftime
+// directives. Unknown placeholders are kept as is.
+func expandPlaceholders(pattern string, values map[string]string, now time.Time, quote bool) string {
+	return placeholderRegex.ReplaceAllStringFunc(pattern, func(placeholder string) string {
+		matches := placeholderRegex.FindStringSubmatch(placeholder)
+		name, format := matches[1], matches[2]
+
+		var value string
+		switch {
+		case name == "now" || name == "utcnow":
+			t := now
+			if name == "utcnow" {
+				t = now.UTC()
+			}
+			if format == "" {
+				format = "%Y-%m-%dT%H:%M:%S"
+			}
+			value = strftime(t, format)
+		case format == "":
+			var ok bool
+			if value, ok = values[name]; !ok {
+				return placeholder
+			}
+		default:
+			return placeholder
+		}
+
+		if quote {
+			return regexp.QuoteMeta(value)
+		}
+		return value
+	})
+}
+
+// strftime formats the time with the common C strftime directives
+func strftime(t time.Time, format string) string {
+	var result strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			result.WriteByte(format[i])
+			continue
+		}
+
+		i++
+		switch format[i] {
+		case 'Y':
+			result.WriteString(t.Format("2006"))
+		case 'y':
+			result.WriteString(t.Format("06"))
+		case 'm':
+			result.WriteString(t.Format("01"))
+		case 'd':
+			result.WriteString(t.Format("02"))
+		case 'H':
+			result.WriteString(t.Format("15"))
+		case 'I':
+			result.WriteString(t.Format("03"))
+		case 'M':
+			result.WriteString(t.Format("04"))
+		case 'S':
+			result.WriteString(t.Format("05"))
+		case 'p':
+			result.WriteString(t.Format("PM"))
+		case 'b':
+			result.WriteString(t.Format("Jan"))
+		case 'B':
+			result.WriteString(t.Format("January"))
+		case 'a':
+			result.WriteString(t.Format("Mon"))
+		case 'A':
+			result.WriteString(t.Format("Monday"))
+		case 'j':
+			result.WriteString(fmt.Sprintf("%03d", t.YearDay()))
+		case 'z':
+			result.WriteString(t.Format("-0700"))
+		case 'Z':
+			result.WriteString(t.Format("MST"))
+		case '%':
+			result.WriteByte('%')
+		default:
+			result.WriteByte('%')
+			result.WriteByte(format[i])
+		}
+	}
+	return result.String()
+}
+
+// multilineValue removes the leading line break and the common indentation
+// of the continuation lines of a multi-line config value
+func multilineValue(value string) string {
+	if !strings.Contains(value, "\n") {
+		return value
+	}
+
+	lines := strings.Split(value, "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == -1 || width < indent {
+			indent = width
+		}
+	}
+	// Values between """ may have no indented continuation lines
+	if indent == -1 {
+		indent = 0
+	}
+	for i, line := range lines[1:] {
+		if len(line) >= indent {
+			lines[i+1] = line[indent:]
+		} else {
+			lines[i+1] = ""
+		}
+	}
+	return strings.TrimPrefix(strings.Join(lines, "\n"), "\n")
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMultilineValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"single line", "{current_version}", "{current_version}"},
+		{"indented lines", "\n    image:\n      tag: {current_version}", "image:\n  tag: {current_version}"},
+		{"first line kept", "## Unreleased\n  ## {new_version}", "## Unreleased\n## {new_version}"},
+		{"blank lines ignored for indent", "\n    a\n\n      b", "a\n\n  b"},
+		{"shorter blank line", "\n    a\n  \n    b", "a\n\nb"},
+		{"tabs", "\n\ta\n\t\tb", "a\n\tb"},
+		{"only blank continuation", "## Unreleased\n", "## Unreleased\n"},
+		{"only blank continuations", "## Unreleased\n\n", "## Unreleased\n\n"},
+		{"unindented lines", "## Unreleased\n\n## {new_version}", "## Unreleased\n\n## {new_version}"},
+	}
+
+	for _, test := range tests {
+		if got := multilineValue(test.value); got != test.want {
+			t.Errorf("%s: multilineValue(%q) = %q, want %q", test.name, test.value, got, test.want)
+		}
+	}
+}
+
+func TestExpandPlaceholders(t *testing.T) {
+	now := time.Date(2026, time.March, 5, 14, 7, 9, 0, time.FixedZone("CET", 3600))
+	values := map[string]string{
+		"current_version": "1.2.3",
+		"new_version":     "1.3.0",
+		"major":           "1",
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		quote   bool
+		want    string
+	}{
+		{"versions", "v{current_version} → v{new_version}", false, "v1.2.3 → v1.3.0"},
+		{"part", "{major}.x", false, "1.x"},
+		{"unknown kept", "{unknown} {current_version}", false, "{unknown} 1.2.3"},
+		{"format on value kept", "{major:02d}", false, "{major:02d}"},
+		{"now", "{now:%Y-%m-%d}", false, "2026-03-05"},
+		{"now default format", "{now}", false, "2026-03-05T14:07:09"},
+		{"utcnow", "{utcnow:%H:%M}", false, "13:07"},
+		{"quoted for regex", `version = "{current_version}"`, true, `version = "1\.2\.3"`},
+		{"regex syntax kept", `^v{current_version}$`, true, `^v1\.2\.3$`},
+		{"json braces kept", `{"version": "{current_version}"}`, false, `{"version": "1.2.3"}`},
+	}
+
+	for _, test := range tests {
+		if got := expandPlaceholders(test.pattern, values, now, test.quote); got != test.want {
+			t.Errorf("%s: expandPlaceholders(%q) = %q, want %q", test.name, test.pattern, got, test.want)
+		}
+	}
+}
+
+func TestStrftime(t *testing.T) {
+	date := time.Date(2026, time.March, 5, 14, 7, 9, 0, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"%Y-%m-%d", "2026-03-05"},
+		{"%y%m%d", "260305"},
+		{"%H:%M:%S", "14:07:09"},
+		{"%I %p", "02 PM"},
+		{"%a %A", "Thu Thursday"},
+		{"%b %B", "Mar March"},
+		{"%j", "064"},
+		{"%z %Z", "+0100 CET"},
+		{"100%%", "100%"},
+		{"%Q", "%Q"},
+		{"trailing %", "trailing %"},
+		{"no directives", "no directives"},
+	}
+
+	for _, test := range tests {
+		if got := strftime(date, test.format); got != test.want {
+			t.Errorf("strftime(%q) = %q, want %q", test.format, got, test.want)
+		}
+	}
+}
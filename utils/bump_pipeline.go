@@ -0,0 +1,239 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Hooks run by the bump pipeline, set as commands in [bumpversion]
+const (
+	HookPreBump   = "pre_bump"
+	HookPostBump  = "post_bump"
+	HookPreCommit = "pre_commit"
+	HookPostTag   = "post_tag"
+)
+
+// BumpHooks are the hooks in the order they run
+var BumpHooks = []string{HookPreBump, HookPostBump, HookPreCommit, HookPostTag}
+
+// BumpPipeline bumps the version: it runs the hooks, updates the files and
+// commits and tags the change when enabled in the config
+type BumpPipeline struct {
+	CurrentVersion string
+	NewVersion     string
+	CommitMessage  string
+	Commit         bool
+	Tag            bool
+	TagName        string
+	// TagMessage is set by RenderTagMessage, which Run calls after the bump
+	// is committed
+	TagMessage string
+	// AllowDirty skips the working tree check
+	AllowDirty bool
+	// Hooks maps a hook name to its commands, in order
+	Hooks map[string][]string
+}
+
+// NewBumpPipeline reads the current version and the config, and computes
+// the new version with bump
+func NewBumpPipeline(bump func(currentVersion string) (string, error)) (*BumpPipeline, error) {
+	currentVersion, err := GetCurrentVersion()
+	if err != nil {
+		return nil, fmt.Errorf("reading current version: %w", err)
+	}
+
+	newVersion, err := bump(currentVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &BumpPipeline{
+		CurrentVersion: currentVersion,
+		NewVersion:     newVersion,
+		Hooks:          make(map[string][]string),
+	}
+
+	p.Commit, err = GetCommitOption()
+	if err != nil {
+		return nil, fmt.Errorf("reading commit option: %w", err)
+	}
+	p.Tag, err = GetTagOption()
+	if err != nil {
+		return nil, fmt.Errorf("reading tag option: %w", err)
+	}
+
+	messages, err := renderBumpMessages(currentVersion, newVersion)
+	if err != nil {
+		return nil, fmt.Errorf("rendering messages: %w", err)
+	}
+	p.CommitMessage = messages.Commit
+	p.TagName = messages.TagName
+
+	config, err := loadBumpConfig()
+	if err != nil {
+		return nil, err
+	}
+	section := config.Section("bumpversion")
+	if section.HasKey("allow_dirty") {
+		p.AllowDirty, err = section.Key("allow_dirty").Bool()
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow_dirty value: %w", err)
+		}
+	}
+	for _, hook := range BumpHooks {
+		if !section.HasKey(hook) {
+			continue
+		}
+		// Each line of a multi-line value is a separate command
+		for _, command := range strings.Split(section.Key(hook).String(), "\n") {
+			if command = strings.TrimSpace(command); command != "" {
+				p.Hooks[hook] = append(p.Hooks[hook], command)
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// HookEnabled reports whether the hook runs, pre_commit only runs when the
+// bump is committed and post_tag when it's tagged
+func (p *BumpPipeline) HookEnabled(hook string) bool {
+	switch hook {
+	case HookPreCommit:
+		return p.Commit
+	case HookPostTag:
+		return p.Tag
+	}
+	return true
+}
+
+// RenderTagMessage renders the tag message. Before the bump is committed
+// the repository variables such as head_sha and commits describe the
+// current HEAD, so the message is only an approximation.
+func (p *BumpPipeline) RenderTagMessage() error {
+	message, err := renderBumpTagMessage(p.CurrentVersion, p.NewVersion, p.TagName)
+	if err != nil {
+		return fmt.Errorf("rendering messages: %w", err)
+	}
+	p.TagMessage = message
+	return nil
+}
+
+// Plan returns the new contents of the files without writing anything
+func (p *BumpPipeline) Plan() ([]FileUpdate, error) {
+	return PlanUpdates(p.CurrentVersion, p.NewVersion)
+}
+
+// CheckWorkingTree returns an error if the repository has changes or is in
+// the middle of an operation, unless a dirty working tree is allowed
+func (p *BumpPipeline) CheckWorkingTree() error {
+	if p.AllowDirty {
+		return nil
+	}
+
+	paths, err := targetPaths(p.CurrentVersion, p.NewVersion)
+	if err != nil {
+		return fmt.Errorf("updating files: %w", err)
+	}
+	return CheckWorkingTree(paths)
+}
+
+// Run applies the bump. A failure before the tag is created rolls back the
+// files, the index and the commit, while a failing post_tag hook leaves the
+// bump in place.
+func (p *BumpPipeline) Run() error {
+	if err := p.CheckWorkingTree(); err != nil {
+		return err
+	}
+
+	if err := p.runHook(HookPreBump); err != nil {
+		return err
+	}
+
+	updates, err := p.Plan()
+	if err != nil {
+		return fmt.Errorf("updating files: %w", err)
+	}
+	tx, err := BeginBump(updates)
+	if err != nil {
+		return fmt.Errorf("updating files: %w", err)
+	}
+	if err := tx.Apply(); err != nil {
+		return fmt.Errorf("updating files: %w", err)
+	}
+
+	if err := p.runHook(HookPostBump); err != nil {
+		return tx.Abort(err)
+	}
+
+	if p.Commit {
+		if err := p.runHook(HookPreCommit); err != nil {
+			return tx.Abort(err)
+		}
+		if err := tx.Commit(p.CurrentVersion, p.NewVersion, p.CommitMessage); err != nil {
+			return tx.Abort(fmt.Errorf("committing changes: %w", err))
+		}
+	}
+
+	if p.Tag {
+		if err := p.RenderTagMessage(); err != nil {
+			return tx.Abort(err)
+		}
+		if err := CreateTag(p.TagName, p.TagMessage); err != nil {
+			return tx.Abort(fmt.Errorf("creating tag: %w", err))
+		}
+		return p.runHook(HookPostTag)
+	}
+
+	return nil
+}
+
+// runHook runs the commands of the hook with the shell, with the versions
+// in the environment
+func (p *BumpPipeline) runHook(hook string) error {
+	for _, command := range p.Hooks[hook] {
+		shell := []string{"sh", "-c", command}
+		if runtime.GOOS == "windows" {
+			shell = []string{"cmd", "/C", command}
+		}
+
+		cmd := exec.Command(shell[0], shell[1:]...)
+		cmd.Env = append(os.Environ(), p.Env()...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s hook '%s': %w", hook, command, err)
+		}
+	}
+	return nil
+}
+
+// Env returns the environment variables set for the hooks
+func (p *BumpPipeline) Env() []string {
+	env := []string{
+		"BUMP_CURRENT_VERSION=" + p.CurrentVersion,
+		"BUMP_NEW_VERSION=" + p.NewVersion,
+	}
+	if p.Tag {
+		env = append(env, "BUMP_TAG_NAME="+p.TagName)
+	}
+	return env
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// inProgressOperations are the files git keeps in the git directory while
+// an operation is in progress
+var inProgressOperations = []struct {
+	path      string
+	operation string
+}{
+	{"MERGE_HEAD", "merge"},
+	{"rebase-merge", "rebase"},
+	{"rebase-apply", "rebase"},
+	{"CHERRY_PICK_HEAD", "cherry-pick"},
+	{"REVERT_HEAD", "revert"},
+	{"BISECT_LOG", "bisect"},
+}
+
+// CheckWorkingTree returns an error listing the staged, unstaged and
+// conflicting files, the untracked files among paths, any merge or rebase in
+// progress and a detached HEAD
+func CheckWorkingTree(paths []string) error {
+	var problems []string
+
+	cmd := exec.Command("git", "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to get the status of the repository: %w", err)
+	}
+
+	targets := make(map[string]bool)
+	for _, path := range paths {
+		targets[filepath.ToSlash(filepath.Clean(path))] = true
+	}
+
+	entries := strings.Split(string(output), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		x, y, path := entry[0], entry[1], entry[3:]
+		// Renames and copies are followed by the original path
+		if x == 'R' || x == 'C' || y == 'R' || y == 'C' {
+			i++
+		}
+
+		switch {
+		case x == '?' && y == '?':
+			if targets[path] {
+				problems = append(problems, "untracked: "+path)
+			}
+		case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+			problems = append(problems, "conflict: "+path)
+		default:
+			if x != ' ' {
+				problems = append(problems, "staged: "+path)
+			}
+			if y != ' ' {
+				problems = append(problems, "unstaged: "+path)
+			}
+		}
+	}
+
+	for _, op := range inProgressOperations {
+		gitPath := gitOutput(".", "rev-parse", "--git-path", op.path)
+		if gitPath == "" {
+			continue
+		}
+		if _, err := os.Stat(gitPath); err == nil {
+			problems = append(problems, fmt.Sprintf("a %s is in progress", op.operation))
+		}
+	}
+
+	// symbolic-ref fails when HEAD points to a commit instead of a branch
+	if err := exec.Command("git", "symbolic-ref", "-q", "HEAD").Run(); err != nil {
+		problems = append(problems, "HEAD is detached")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("git directory is not clean, commit or stash the changes before running the bump, or allow it with --allow-dirty or allow_dirty = True:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
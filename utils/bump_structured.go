@@ -0,0 +1,455 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Formats of the structured files whose values can be targeted by key
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+	FormatGo   = "go"
+)
+
+var yamlKeyRegex = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s#'"][^:#]*?)\s*:(?:\s+|$)`)
+
+var tomlKeyRegex = regexp.MustCompile(`^((?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*')(?:\s*\.\s*(?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*'))*)\s*=\s*`)
+
+// structuredFormat returns the format of the file, set explicitly or
+// detected from its extension
+func structuredFormat(path, format string) (string, error) {
+	switch format {
+	case FormatJSON, FormatYAML, FormatTOML, FormatGo:
+		return format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unknown format '%s', expected one of: json, yaml, toml, go", format)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yml", ".yaml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	case ".go":
+		return FormatGo, nil
+	}
+	return "", fmt.Errorf("can't detect the format of %s, set format to json, yaml, toml or go", path)
+}
+
+// locateValue returns the byte offsets of the string value of the key in
+// the data, without its quotes. Keys of JSON, YAML and TOML files are dotted
+// paths such as tool.poetry.version, keys of Go files are const names.
+func locateValue(format, data, key string) (int, int, error) {
+	var start, end int
+	var err error
+	switch format {
+	case FormatJSON:
+		start, end, err = locateJSONValue(data, splitKey(key))
+	case FormatYAML:
+		start, end, err = locateYAMLValue(data, splitKey(key))
+	case FormatTOML:
+		start, end, err = locateTOMLValue(data, splitKey(key))
+	case FormatGo:
+		start, end, err = locateGoConst(data, key)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	if start < 0 {
+		return 0, 0, fmt.Errorf("key '%s' not found", key)
+	}
+	return start, end, nil
+}
+
+// splitKey splits a dotted key, where parts can be quoted to contain dots
+func splitKey(key string) []string {
+	var parts []string
+	var part strings.Builder
+	quote := byte(0)
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			part.WriteByte(c)
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '.':
+			parts = append(parts, strings.TrimSpace(part.String()))
+			part.Reset()
+		default:
+			part.WriteByte(c)
+		}
+	}
+	return append(parts, strings.TrimSpace(part.String()))
+}
+
+func equalKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonScanner walks a JSON document to find the string value at a path
+type jsonScanner struct {
+	data       string
+	pos        int
+	key        []string
+	start, end int
+}
+
+func locateJSONValue(data string, key []string) (int, int, error) {
+	s := &jsonScanner{data: data, key: key, start: -1}
+	if err := s.value(nil); err != nil {
+		return 0, 0, err
+	}
+	return s.start, s.end, nil
+}
+
+func (s *jsonScanner) skipSpace() {
+	for s.pos < len(s.data) && strings.IndexByte(" \t\r\n", s.data[s.pos]) != -1 {
+		s.pos++
+	}
+}
+
+func (s *jsonScanner) errorf(format string, args ...interface{}) error {
+	line := strings.Count(s.data[:s.pos], "\n") + 1
+	return fmt.Errorf("invalid JSON on line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (s *jsonScanner) expect(c byte) error {
+	s.skipSpace()
+	if s.pos >= len(s.data) || s.data[s.pos] != c {
+		return s.errorf("expected '%c'", c)
+	}
+	s.pos++
+	return nil
+}
+
+// str scans a string and returns the offsets of its contents
+func (s *jsonScanner) str() (int, int, error) {
+	if err := s.expect('"'); err != nil {
+		return 0, 0, err
+	}
+	start := s.pos
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case '\\':
+			s.pos += 2
+		case '"':
+			s.pos++
+			return start, s.pos - 1, nil
+		default:
+			s.pos++
+		}
+	}
+	return 0, 0, s.errorf("unterminated string")
+}
+
+func (s *jsonScanner) value(path []string) error {
+	s.skipSpace()
+	if s.pos >= len(s.data) {
+		return s.errorf("unexpected end of file")
+	}
+
+	match := s.start < 0 && equalKeys(path, s.key)
+	switch s.data[s.pos] {
+	case '{':
+		s.pos++
+		s.skipSpace()
+		if s.pos < len(s.data) && s.data[s.pos] == '}' {
+			s.pos++
+			break
+		}
+		for {
+			start, end, err := s.str()
+			if err != nil {
+				return err
+			}
+			if err := s.expect(':'); err != nil {
+				return err
+			}
+			child := append(append([]string{}, path...), s.data[start:end])
+			if err := s.value(child); err != nil {
+				return err
+			}
+			s.skipSpace()
+			if s.pos < len(s.data) && s.data[s.pos] == ',' {
+				s.pos++
+				continue
+			}
+			if err := s.expect('}'); err != nil {
+				return err
+			}
+			break
+		}
+	case '[':
+		s.pos++
+		s.skipSpace()
+		if s.pos < len(s.data) && s.data[s.pos] == ']' {
+			s.pos++
+			break
+		}
+		for i := 0; ; i++ {
+			child := append(append([]string{}, path...), strconv.Itoa(i))
+			if err := s.value(child); err != nil {
+				return err
+			}
+			s.skipSpace()
+			if s.pos < len(s.data) && s.data[s.pos] == ',' {
+				s.pos++
+				continue
+			}
+			if err := s.expect(']'); err != nil {
+				return err
+			}
+			break
+		}
+	case '"':
+		start, end, err := s.str()
+		if err != nil {
+			return err
+		}
+		if match {
+			s.start, s.end = start, end
+		}
+		return nil
+	default:
+		for s.pos < len(s.data) && strings.IndexByte(",}] \t\r\n", s.data[s.pos]) == -1 {
+			s.pos++
+		}
+	}
+
+	if match {
+		return fmt.Errorf("value of key '%s' is not a string", strings.Join(s.key, "."))
+	}
+	return nil
+}
+
+// yamlKey is a mapping key of the YAML block being scanned
+type yamlKey struct {
+	indent int
+	name   string
+}
+
+// locateYAMLValue scans the block mappings of a YAML document line by line.
+// Sequence items aren't addressable, and flow mappings aren't supported.
+func locateYAMLValue(data string, key []string) (int, int, error) {
+	var stack []yamlKey
+	blockIndent := -1
+	offset := 0
+	for _, line := range strings.SplitAfter(data, "\n") {
+		lineOffset := offset
+		offset += len(line)
+		line = strings.TrimRight(line, "\r\n")
+
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		// Skip the lines of block scalars (| and >)
+		if blockIndent >= 0 {
+			if indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+		if trimmed == "---" || trimmed == "..." {
+			stack = nil
+			continue
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			stack = append(stack, yamlKey{indent, "-"})
+			continue
+		}
+
+		match := yamlKeyRegex.FindStringSubmatchIndex(trimmed)
+		if match == nil {
+			continue
+		}
+		name := trimmed[match[2]:match[3]]
+		if len(name) >= 2 && (name[0] == '"' || name[0] == '\'') {
+			name = name[1 : len(name)-1]
+		}
+		stack = append(stack, yamlKey{indent, name})
+
+		value := trimmed[match[1]:]
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockIndent = indent
+			continue
+		}
+		if value == "" || strings.HasPrefix(value, "#") {
+			continue
+		}
+
+		path := make([]string, len(stack))
+		for i, k := range stack {
+			path[i] = k.name
+		}
+		if !equalKeys(path, key) {
+			continue
+		}
+
+		start := lineOffset + indent + match[1]
+		if value[0] == '"' || value[0] == '\'' {
+			closing := strings.IndexByte(value[1:], value[0])
+			if closing == -1 {
+				return 0, 0, fmt.Errorf("unterminated string for key '%s'", strings.Join(key, "."))
+			}
+			return start + 1, start + 1 + closing, nil
+		}
+		if comment := strings.Index(value, " #"); comment != -1 {
+			value = value[:comment]
+		}
+		return start, start + len(strings.TrimRight(value, " \t")), nil
+	}
+
+	return -1, -1, nil
+}
+
+// locateTOMLValue scans the tables and key/value pairs of a TOML document
+// line by line. Arrays of tables and inline tables aren't addressable.
+func locateTOMLValue(data string, key []string) (int, int, error) {
+	var table []string
+	multiline := ""
+	offset := 0
+	for _, line := range strings.SplitAfter(data, "\n") {
+		lineOffset := offset
+		offset += len(line)
+		line = strings.TrimRight(line, "\r\n")
+
+		if multiline != "" {
+			if strings.Contains(line, multiline) {
+				multiline = ""
+			}
+			continue
+		}
+
+		trimmed := strings.TrimLeft(line, " \t")
+		indent := len(line) - len(trimmed)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case strings.HasPrefix(trimmed, "[["):
+			end := strings.Index(trimmed, "]]")
+			if end == -1 {
+				return 0, 0, fmt.Errorf("invalid TOML table header: %s", trimmed)
+			}
+			table = append(splitKey(trimmed[2:end]), "[]")
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			end := strings.Index(trimmed, "]")
+			if end == -1 {
+				return 0, 0, fmt.Errorf("invalid TOML table header: %s", trimmed)
+			}
+			table = splitKey(trimmed[1:end])
+			continue
+		}
+
+		match := tomlKeyRegex.FindStringSubmatchIndex(trimmed)
+		if match == nil {
+			continue
+		}
+		value := trimmed[match[1]:]
+		for _, delimiter := range []string{`"""`, `'''`} {
+			if strings.HasPrefix(value, delimiter) && !strings.Contains(value[3:], delimiter) {
+				multiline = delimiter
+			}
+		}
+		if multiline != "" {
+			continue
+		}
+
+		path := append(append([]string{}, table...), splitKey(trimmed[match[2]:match[3]])...)
+		if !equalKeys(path, key) {
+			continue
+		}
+		if value == "" || (value[0] != '"' && value[0] != '\'') {
+			return 0, 0, fmt.Errorf("value of key '%s' is not a string", strings.Join(key, "."))
+		}
+
+		start := lineOffset + indent + match[1] + 1
+		for i := 1; i < len(value); i++ {
+			if value[0] == '"' && value[i] == '\\' {
+				i++
+				continue
+			}
+			if value[i] == value[0] {
+				return start, start + i - 1, nil
+			}
+		}
+		return 0, 0, fmt.Errorf("unterminated string for key '%s'", strings.Join(key, "."))
+	}
+
+	return -1, -1, nil
+}
+
+// locateGoConst finds the string value of a const declaration in Go source
+func locateGoConst(data, name string) (int, int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", data, parser.SkipObjectResolution)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Go source: %w", err)
+	}
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			valueSpec := spec.(*ast.ValueSpec)
+			for i, ident := range valueSpec.Names {
+				if ident.Name != name || i >= len(valueSpec.Values) {
+					continue
+				}
+				lit, ok := valueSpec.Values[i].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					return 0, 0, fmt.Errorf("const %s is not a string literal", name)
+				}
+				start := fset.Position(lit.Pos()).Offset
+				return start + 1, start + len(lit.Value) - 1, nil
+			}
+		}
+	}
+
+	return -1, -1, nil
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import "testing"
+
+func TestLocateValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		key    string
+		want   string
+	}{
+		{
+			name:   "json top-level",
+			format: FormatJSON,
+			data:   `{"name": "app", "version": "1.2.3"}`,
+			key:    "version",
+			want:   "1.2.3",
+		},
+		{
+			name:   "json nested after arrays and objects",
+			format: FormatJSON,
+			data: `{
+  "version": "0.0.1",
+  "files": ["version", {"version": "x"}],
+  "dependencies": {"lib": "^1.2.3", "other": "~1.0.0"}
+}`,
+			key:  "dependencies.lib",
+			want: "^1.2.3",
+		},
+		{
+			name:   "json quoted key with dots",
+			format: FormatJSON,
+			data:   `{"a.b": {"c": "1.0.0"}, "a": {"b": "2.0.0"}}`,
+			key:    `"a.b".c`,
+			want:   "1.0.0",
+		},
+		{
+			name:   "json escaped strings",
+			format: FormatJSON,
+			data:   `{"note": "say \"version\": \"0\"", "version": "1.2.3"}`,
+			key:    "version",
+			want:   "1.2.3",
+		},
+		{
+			name:   "yaml nested",
+			format: FormatYAML,
+			data: `app:
+  name: web
+  image:
+    tag: 1.2.3 # current
+other:
+  image:
+    tag: 0.0.1
+`,
+			key:  "app.image.tag",
+			want: "1.2.3",
+		},
+		{
+			name:   "yaml quoted value",
+			format: FormatYAML,
+			data:   "version: \"1.2.3\"\n",
+			key:    "version",
+			want:   "1.2.3",
+		},
+		{
+			name:   "yaml skips block scalars and comments",
+			format: FormatYAML,
+			data: `# version: 0.0.0
+description: |
+  version: 0.0.1
+version: '1.2.3'
+`,
+			key:  "version",
+			want: "1.2.3",
+		},
+		{
+			name:   "toml table",
+			format: FormatTOML,
+			data: `version = "0.0.1"
+
+[tool.poetry]
+name = "app"
+version = "1.2.3"
+`,
+			key:  "tool.poetry.version",
+			want: "1.2.3",
+		},
+		{
+			name:   "toml dotted key",
+			format: FormatTOML,
+			data:   "[tool]\npoetry.version = '1.2.3'\n",
+			key:    "tool.poetry.version",
+			want:   "1.2.3",
+		},
+		{
+			name:   "toml skips multi-line strings and array tables",
+			format: FormatTOML,
+			data: `description = """
+version = "0.0.1"
+"""
+
+[[bin]]
+version = "0.0.2"
+
+[package]
+version = "1.2.3"
+`,
+			key:  "package.version",
+			want: "1.2.3",
+		},
+		{
+			name:   "go const block",
+			format: FormatGo,
+			data: `package main
+
+const (
+	name    = "app"
+	Version = "1.2.3"
+)
+`,
+			key:  "Version",
+			want: "1.2.3",
+		},
+		{
+			name:   "go raw string",
+			format: FormatGo,
+			data:   "package main\n\nconst Version = `1.2.3`\n",
+			key:    "Version",
+			want:   "1.2.3",
+		},
+	}
+
+	for _, test := range tests {
+		start, end, err := locateValue(test.format, test.data, test.key)
+		if err != nil {
+			t.Errorf("%s: locateValue(%q) returned error: %v", test.name, test.key, err)
+			continue
+		}
+		if got := test.data[start:end]; got != test.want {
+			t.Errorf("%s: locateValue(%q) = %q, want %q", test.name, test.key, got, test.want)
+		}
+	}
+}
+
+func TestLocateValueErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		key    string
+	}{
+		{"json missing key", FormatJSON, `{"version": "1.2.3"}`, "app.version"},
+		{"json number value", FormatJSON, `{"version": 1}`, "version"},
+		{"yaml missing key", FormatYAML, "app:\n  name: web\n", "app.version"},
+		{"yaml list items", FormatYAML, "versions:\n  - 1.2.3\n", "versions.0"},
+		{"toml missing table", FormatTOML, "version = \"1.2.3\"\n", "package.version"},
+		{"toml array of tables", FormatTOML, "[[bin]]\nversion = \"1.2.3\"\n", "bin.version"},
+		{"go missing const", FormatGo, "package main\n\nvar Version = \"1.2.3\"\n", "Version"},
+		{"go invalid source", FormatGo, "const Version = \"1.2.3\"\n", "Version"},
+	}
+
+	for _, test := range tests {
+		if _, _, err := locateValue(test.format, test.data, test.key); err == nil {
+			t.Errorf("%s: locateValue(%q) returned no error", test.name, test.key)
+		}
+	}
+}
+
+func TestSplitKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"version", []string{"version"}},
+		{"tool.poetry.version", []string{"tool", "poetry", "version"}},
+		{`"a.b".c`, []string{"a.b", "c"}},
+		{`deps.'@scope/pkg'`, []string{"deps", "@scope/pkg"}},
+	}
+
+	for _, test := range tests {
+		if got := splitKey(test.key); !equalKeys(got, test.want) {
+			t.Errorf("splitKey(%q) = %q, want %q", test.key, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultCommitMessage = "Bump version: {current_version} → {new_version}"
+	defaultTagMessage    = "Version {new_version}"
+)
+
+// templateActionRegex matches the {{ ... }} actions of a text/template
+var templateActionRegex = regexp.MustCompile(`\{\{.*?\}\}`)
+
+var templateFieldRegex = regexp.MustCompile(`\.(\w+)`)
+
+// BumpMessages are the rendered templates of the bump commit and tag name
+type BumpMessages struct {
+	Commit  string
+	TagName string
+}
+
+// renderBumpMessages renders the message and tag_name templates of
+// [bumpversion] with the same engine as the tag command
+func renderBumpMessages(currentVersion, newVersion string) (BumpMessages, error) {
+	var messages BumpMessages
+
+	config, err := loadBumpConfig()
+	if err != nil {
+		return messages, err
+	}
+	section := config.Section("bumpversion")
+
+	_, variables := bumpPlaceholders(currentVersion, newVersion)
+
+	if section.HasKey("tag_name") {
+		messages.TagName, err = ParseTemplate(section.Key("tag_name").String(), variables)
+		if err != nil {
+			return messages, fmt.Errorf("tag_name: %w", err)
+		}
+	} else {
+		messages.TagName, err = GetTagName(newVersion)
+		if err != nil {
+			return messages, err
+		}
+	}
+	variables["newTag"] = messages.TagName
+
+	commitTemplate := defaultCommitMessage
+	if section.HasKey("message") {
+		commitTemplate = multilineValue(section.Key("message").String())
+	}
+	messages.Commit, err = renderBumpTemplate(commitTemplate, variables, messages.TagName)
+	if err != nil {
+		return messages, fmt.Errorf("message: %w", err)
+	}
+
+	return messages, nil
+}
+
+// renderBumpTagMessage renders the tag_message template of [bumpversion],
+// which can also be a keyword of the tag messages of the app or repository
+// config. The repository variables such as head_sha and commits describe
+// HEAD, so the message is rendered once the bump is committed.
+func renderBumpTagMessage(currentVersion, newVersion, tagName string) (string, error) {
+	config, err := loadBumpConfig()
+	if err != nil {
+		return "", err
+	}
+	section := config.Section("bumpversion")
+
+	_, variables := bumpPlaceholders(currentVersion, newVersion)
+	variables["newTag"] = tagName
+
+	tagTemplate := defaultTagMessage
+	if section.HasKey("tag_message") {
+		tagTemplate = multilineValue(section.Key("tag_message").String())
+
+		keywords, err := GetTagMessages(".")
+		if err != nil {
+			return "", err
+		}
+		if message, ok := keywords[tagTemplate]; ok {
+			tagTemplate = message
+		}
+	}
+	message, err := renderBumpTemplate(tagTemplate, variables, tagName)
+	if err != nil {
+		return "", fmt.Errorf("tag_message: %w", err)
+	}
+	return message, nil
+}
+
+// renderBumpTemplate renders the template with the version variables, and
+// the variables of the tag command (repo_name, prevTag, commits, ...) when
+// the template uses them, so that bumps work without an origin remote
+func renderBumpTemplate(message string, variables map[string]string, tagName string) (string, error) {
+	if !usesOtherVariables(message, variables) {
+		return ParseTemplate(message, variables)
+	}
+
+	prevTag, err := GetPreviousTag(".", tagName, PreviousTagOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get the previous tag: %w", err)
+	}
+	templateVariables, err := CreateTemplateVariables(".", prevTag, tagName, message)
+	if err != nil {
+		return "", err
+	}
+	for name, value := range variables {
+		templateVariables[name] = value
+	}
+	return ParseTemplate(message, templateVariables)
+}
+
+// usesOtherVariables reports whether the template references a variable
+// that isn't in variables
+func usesOtherVariables(message string, variables map[string]string) bool {
+	var names []string
+	if strings.Contains(message, "{{") {
+		for _, action := range templateActionRegex.FindAllString(message, -1) {
+			for _, match := range templateFieldRegex.FindAllStringSubmatch(action, -1) {
+				names = append(names, match[1])
+			}
+		}
+	} else {
+		for _, match := range placeholderRegex.FindAllStringSubmatch(message, -1) {
+			names = append(names, match[1])
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := variables[name]; !ok {
+			return true
+		}
+	}
+	return false
+}
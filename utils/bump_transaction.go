@@ -0,0 +1,193 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// BumpTransaction writes, stages and commits the files of a version bump,
+// and restores the repository to its previous state when a step fails
+type BumpTransaction struct {
+	updates []FileUpdate
+	// head is the commit before the bump, empty on an unborn branch
+	head string
+	// index holds the index entries of the files before the bump, in the
+	// format of git update-index --index-info -z
+	index     []byte
+	written   []FileUpdate
+	staged    bool
+	committed bool
+}
+
+// BeginBump records the state of the repository before the updates are
+// applied
+func BeginBump(updates []FileUpdate) (*BumpTransaction, error) {
+	t := &BumpTransaction{updates: updates}
+
+	output, err := exec.Command("git", "rev-parse", "--verify", "-q", "HEAD").Output()
+	if err == nil {
+		t.head = strings.TrimSpace(string(output))
+	}
+
+	var paths []string
+	for _, update := range updates {
+		paths = append(paths, update.Path)
+	}
+	args := append([]string{"--literal-pathspecs", "ls-files", "-s", "-z", "--"}, paths...)
+	output, err = exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the index: %w", err)
+	}
+	t.index = output
+
+	// Files that aren't in the index are removed from it on rollback
+	for _, path := range paths {
+		path = filepath.ToSlash(filepath.Clean(path))
+		if !bytes.Contains(output, []byte("\t"+path+"\x00")) {
+			t.index = append(t.index, []byte("0 "+zeroSHA+"\t"+path+"\x00")...)
+		}
+	}
+
+	return t, nil
+}
+
+// Apply writes the new contents of the files and stages them. Nothing is
+// written until all the new contents are computed, and the files written
+// before a failure are rolled back.
+func (t *BumpTransaction) Apply() error {
+	for _, update := range t.updates {
+		err := writeFileAtomic(update.Path, update.NewData)
+		if err != nil {
+			return t.Abort(fmt.Errorf("failed to write file: %s: %w", update.Path, err))
+		}
+		t.written = append(t.written, update)
+	}
+
+	var paths []string
+	for _, update := range t.updates {
+		paths = append(paths, update.Path)
+	}
+	t.staged = true
+	args := append([]string{"--literal-pathspecs", "add", "--"}, paths...)
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return t.Abort(fmt.Errorf("failed to stage files: %s", strings.TrimSpace(string(output))))
+	}
+
+	return nil
+}
+
+// Commit commits the staged files
+func (t *BumpTransaction) Commit(currentVersion, newVersion, message string) error {
+	err := CommitChanges(currentVersion, newVersion, message)
+	if err != nil {
+		return err
+	}
+	t.committed = true
+	return nil
+}
+
+// Rollback undoes the commit, restores the index entries and the contents
+// of the files, and returns a description of every step rolled back
+func (t *BumpTransaction) Rollback() ([]string, error) {
+	var steps []string
+
+	if t.committed {
+		args := []string{"reset", "-q", "--soft", t.head}
+		if t.head == "" {
+			args = []string{"update-ref", "-d", "HEAD"}
+		}
+		output, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			return steps, fmt.Errorf("failed to undo the commit: %s", strings.TrimSpace(string(output)))
+		}
+		t.committed = false
+		steps = append(steps, "removed the bump commit")
+	}
+
+	if t.staged {
+		cmd := exec.Command("git", "update-index", "-z", "--index-info")
+		cmd.Stdin = bytes.NewReader(t.index)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return steps, fmt.Errorf("failed to restore the index: %s", strings.TrimSpace(string(output)))
+		}
+		t.staged = false
+		for _, update := range t.updates {
+			steps = append(steps, "unstaged "+update.Path)
+		}
+	}
+
+	for len(t.written) > 0 {
+		update := t.written[len(t.written)-1]
+		err := writeFileAtomic(update.Path, update.OldData)
+		if err != nil {
+			return steps, fmt.Errorf("failed to restore file: %s: %w", update.Path, err)
+		}
+		t.written = t.written[:len(t.written)-1]
+		steps = append(steps, "restored "+update.Path)
+	}
+
+	return steps, nil
+}
+
+// Abort rolls back the transaction after err and adds the rolled back steps
+// to it
+func (t *BumpTransaction) Abort(err error) error {
+	steps, rollbackErr := t.Rollback()
+	if rollbackErr != nil {
+		return fmt.Errorf("%w\nrollback failed: %v", err, rollbackErr)
+	}
+	if len(steps) > 0 {
+		return fmt.Errorf("%w\nRolled back:\n  %s", err, strings.Join(steps, "\n  "))
+	}
+	return err
+}
+
+// writeFileAtomic writes the file through a temporary file in the same
+// directory that is renamed over it, keeping its permissions
+func writeFileAtomic(path, data string) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -4,16 +4,31 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"strconv"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/go-ini/ini"
 )
 
 var bump_cfg = ".git-utils-bump.cfg"
 
+// sectionHeaderRegex and currentVersionRegex match the lines of the config
+// rewritten by the bump
+var (
+	sectionHeaderRegex  = regexp.MustCompile(`^\[([^\]]*)\]`)
+	currentVersionRegex = regexp.MustCompile(`^(current_version[ \t]*[=:][ \t]*)[^\r\n]*?([ \t]*\r?\n?)$`)
+)
+
+// loadBumpConfig loads the bump config, allowing bump2version style
+// indented multi-line values. Inline comments are disabled so that search
+// patterns can contain # and ;
+func loadBumpConfig() (*ini.File, error) {
+	return ini.LoadSources(ini.LoadOptions{AllowPythonMultilineValues: true, IgnoreInlineComment: true}, bump_cfg)
+}
+
 func GetCurrentVersion() (string, error) {
-	config, err := ini.Load(bump_cfg)
+	config, err := loadBumpConfig()
 	if err != nil {
 		return "", err
 	}
@@ -21,124 +36,278 @@ func GetCurrentVersion() (string, error) {
 	return config.Section("bumpversion").Key("current_version").String(), nil
 }
 
-func IncrementVersion(version string) string {
-	parts := strings.Split(version, ".")
-	lastPart := parts[len(parts)-1]
-	lastPart = strings.TrimPrefix(lastPart, "v")
-	updatedLastPart := strconv.Itoa(parseInt(lastPart) + 1)
-	parts[len(parts)-1] = updatedLastPart
-	return strings.Join(parts, ".")
+// IncrementVersion bumps the patch version, or the last part of a custom
+// version scheme
+func IncrementVersion(version string) (string, error) {
+	scheme, err := getVersionScheme()
+	if err != nil {
+		return "", err
+	}
+	if scheme != nil {
+		return scheme.Bump(version, scheme.LastPart())
+	}
+	return BumpVersionPart(version, "patch")
 }
 
-func BumpMajorVersion(version string) string {
-	parts := strings.Split(version, ".")
-	parts[0] = strconv.Itoa(parseInt(parts[0]) + 1)
-	parts[1] = "0"
-	parts[2] = "0"
-	return strings.Join(parts, ".")
+func BumpMajorVersion(version string) (string, error) {
+	return BumpVersionPart(version, "major")
 }
 
-func BumpMinorVersion(version string) string {
-	parts := strings.Split(version, ".")
-	parts[1] = strconv.Itoa(parseInt(parts[1]) + 1)
-	parts[2] = "0"
-	return strings.Join(parts, ".")
+func BumpMinorVersion(version string) (string, error) {
+	return BumpVersionPart(version, "minor")
 }
 
-func BumpPatchVersion(version string) string {
-	parts := strings.Split(version, ".")
-	parts[2] = strconv.Itoa(parseInt(parts[2]) + 1)
-	return strings.Join(parts, ".")
+func BumpPatchVersion(version string) (string, error) {
+	return BumpVersionPart(version, "patch")
 }
 
-func UpdateFiles(currentVersion, newVersion string) error {
+// BumpVersionPart bumps a part of the version. Semantic versions keep a
+// leading "v", custom version schemes can bump any of their parts.
+func BumpVersionPart(version, part string) (string, error) {
+	scheme, err := getVersionScheme()
+	if err != nil {
+		return "", err
+	}
+	if scheme != nil {
+		return scheme.Bump(version, part)
+	}
+
+	return modifyVersion(version, func(v Version) (Version, error) {
+		return v.Bump(part)
+	})
+}
 
-	// Check if the Git directory is dirty
-	if err := checkGitDirectoryStatus(); err != nil {
-		fmt.Println("Git directory is dirty.\nPlease stage, commit, or stash your changes before running the bump.")
-		os.Exit(1)
+// BumpCalVer returns the next calendar version for today's date, using the
+// calver_format of the config (YYYY.MM.MICRO by default)
+func BumpCalVer(version string) (string, error) {
+	config, err := loadBumpConfig()
+	if err != nil {
+		return "", err
 	}
-	config, err := ini.Load(bump_cfg)
+
+	format := config.Section("bumpversion").Key("calver_format").MustString(DefaultCalVerFormat)
+	calver, err := ParseCalVerFormat(format)
 	if err != nil {
-		return err
+		return "", err
 	}
+	return calver.Next(version, time.Now())
+}
 
-	sections := config.SectionStrings()
+// getVersionScheme returns the custom version scheme, or nil if semantic
+// versioning is used
+func getVersionScheme() (*VersionScheme, error) {
+	config, err := loadBumpConfig()
+	if err != nil {
+		return nil, err
+	}
+	return loadVersionScheme(config)
+}
 
-	// Check if search pattern exists in all the files
-	for _, section := range sections {
-		if strings.HasPrefix(section, "bumpversion:file:") {
-			filePath := strings.TrimPrefix(section, "bumpversion:file:")
-			searchPattern := config.Section(section).Key("search").String()
+// BumpPartWithPrerelease bumps a part of the version and starts a
+// "<label>.1" pre-release of the new version. The part is bumped from the
+// release, so a pre-release always moves to the next version.
+func BumpPartWithPrerelease(version, part, label string) (string, error) {
+	labels, err := GetPrereleaseLabels()
+	if err != nil {
+		return "", err
+	}
+	if indexOf(labels, label) == -1 {
+		return "", fmt.Errorf("unknown pre-release label '%s', expected one of: %s", label, strings.Join(labels, ", "))
+	}
 
-			// Check if the search pattern exists in the file
-			if !fileContainsString(filePath, strings.ReplaceAll(searchPattern, "{current_version}", currentVersion)) {
-				return fmt.Errorf("search pattern not found in file: %s", filePath)
-			}
+	return modifyVersion(version, func(v Version) (Version, error) {
+		release := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+		next, err := release.Bump(part)
+		if err != nil {
+			return Version{}, err
 		}
-	}
-	for _, section := range sections {
-		if strings.HasPrefix(section, "bumpversion:file:") {
-			filePath := strings.TrimPrefix(section, "bumpversion:file:")
-			searchPattern := config.Section(section).Key("search").String()
-			replacePattern := config.Section(section).Key("replace").String()
+		return next.WithPrerelease(label), nil
+	})
+}
 
-			err := replaceStringInFile(filePath, searchPattern, replacePattern, currentVersion, newVersion)
-			if err != nil {
-				return err
-			}
+// BumpPrereleaseVersion increments the number of the current pre-release,
+// e.g. 1.2.3-rc.1 to 1.2.3-rc.2
+func BumpPrereleaseVersion(version string) (string, error) {
+	labels, err := GetPrereleaseLabels()
+	if err != nil {
+		return "", err
+	}
 
-			// Stage the updated file
-			cmd := exec.Command("git", "add", filePath)
-			err = cmd.Run()
-			if err != nil {
-				return fmt.Errorf("failed to stage file: %s", filePath)
-			}
+	return modifyVersion(version, func(v Version) (Version, error) {
+		if !v.IsPrerelease() {
+			return Version{}, fmt.Errorf("version %s is not a pre-release, start one with: git-utils bump pre --label <label>", v)
+		}
+		label, _, err := v.prereleaseParts()
+		if err != nil {
+			return Version{}, err
 		}
+		return v.BumpPrerelease(label, labels)
+	})
+}
+
+// BumpPrereleaseLabel moves the version to the pre-release label, e.g.
+// 1.2.3-alpha.2 to 1.2.3-beta.1, or 1.2.3 to 1.2.4-beta.1
+func BumpPrereleaseLabel(version, label string) (string, error) {
+	labels, err := GetPrereleaseLabels()
+	if err != nil {
+		return "", err
 	}
-	config.Section("bumpversion").Key("current_version").SetValue(newVersion)
-	err = config.SaveTo(bump_cfg)
+
+	return modifyVersion(version, func(v Version) (Version, error) {
+		return v.BumpPrerelease(label, labels)
+	})
+}
+
+// ReleaseVersion finalizes a pre-release, e.g. 1.2.3-rc.2 to 1.2.3
+func ReleaseVersion(version string) (string, error) {
+	return modifyVersion(version, func(v Version) (Version, error) {
+		return v.Release()
+	})
+}
+
+// modifyVersion parses the current version, keeping a leading "v", and
+// formats the version returned by modify
+func modifyVersion(version string, modify func(v Version) (Version, error)) (string, error) {
+	scheme, err := getVersionScheme()
 	if err != nil {
-		return err
+		return "", err
+	}
+	if scheme != nil {
+		return "", fmt.Errorf("pre-release bumps are only supported for semantic versions, not with a custom parse in %s", bump_cfg)
 	}
 
-	// Stage the updated file
-	cmd := exec.Command("git", "add", bump_cfg)
-	err = cmd.Run()
+	prefix := ""
+	if strings.HasPrefix(version, "v") {
+		prefix = "v"
+	}
+
+	v, err := ParseVersion(strings.TrimPrefix(version, prefix))
 	if err != nil {
-		return fmt.Errorf("failed to stage file: %s", bump_cfg)
+		return "", fmt.Errorf("invalid current_version '%s' in %s: expected a semantic version such as 1.2.3 or 1.2.3-rc.1+build.5", version, bump_cfg)
 	}
 
-	return nil
+	next, err := modify(v)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + next.String(), nil
 }
 
-func fileContainsString(filename, searchPattern string) bool {
-	data, err := os.ReadFile(filename)
+// GetPrereleaseLabels returns the ordered pre-release labels set with
+// prerelease_labels, alpha, beta and rc by default
+func GetPrereleaseLabels() ([]string, error) {
+	config, err := loadBumpConfig()
 	if err != nil {
-		return false
+		return nil, err
+	}
+
+	key := config.Section("bumpversion").Key("prerelease_labels")
+	if key.String() == "" {
+		return []string{"alpha", "beta", "rc"}, nil
 	}
 
-	return strings.Contains(string(data), searchPattern)
+	var labels []string
+	for _, label := range key.Strings(",") {
+		if label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels, nil
+}
+
+// FileUpdate is the new contents of a file changed by the version bump
+type FileUpdate struct {
+	Path    string
+	OldData string
+	NewData string
+}
+
+// Diff returns the unified diff of the update
+func (u FileUpdate) Diff() string {
+	return UnifiedDiff(u.Path, u.OldData, u.NewData, 3)
 }
 
-func replaceStringInFile(filename, searchPattern, replacePattern, currentVersion, newVersion string) error {
-	data, err := os.ReadFile(filename)
+// PlanUpdates checks that the search pattern of every file section is found
+// and returns the new contents of the files and of the bump config, without
+// writing anything
+func PlanUpdates(currentVersion, newVersion string) ([]FileUpdate, error) {
+	config, err := loadBumpConfig()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Replace the search pattern with the new version
-	newData := strings.ReplaceAll(string(data), strings.ReplaceAll(searchPattern, "{current_version}", currentVersion), strings.ReplaceAll(replacePattern, "{new_version}", newVersion))
-	err = os.WriteFile(filename, []byte(newData), 0644)
+	targets, err := getFileTargets(config, currentVersion, newVersion)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// Check if the search pattern exists in all the files
+	var updates []FileUpdate
+	index := make(map[string]int)
+	for _, target := range targets {
+		i, ok := index[target.Path]
+		if !ok {
+			content, err := os.ReadFile(target.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file: %s: %w", target.Path, err)
+			}
+			i = len(updates)
+			index[target.Path] = i
+			updates = append(updates, FileUpdate{Path: target.Path, OldData: string(content), NewData: string(content)})
+		}
+
+		if err := target.Check(updates[i].OldData); err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply every search and replace pair of a file in order
+	for _, target := range targets {
+		update := &updates[index[target.Path]]
+		update.NewData, err = target.Apply(update.NewData)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return nil
+	oldConfig, err := os.ReadFile(bump_cfg)
+	if err != nil {
+		return nil, err
+	}
+	newConfig, err := setCurrentVersion(string(oldConfig), newVersion)
+	if err != nil {
+		return nil, err
+	}
+	updates = append(updates, FileUpdate{Path: bump_cfg, OldData: string(oldConfig), NewData: newConfig})
+
+	return updates, nil
+}
+
+// setCurrentVersion replaces the value of current_version in the
+// [bumpversion] section of the config, leaving the rest of the file as is.
+// Indented lines are continuations of multi-line values and are skipped.
+func setCurrentVersion(config, newVersion string) (string, error) {
+	lines := strings.SplitAfter(config, "\n")
+	section := ""
+	for i, line := range lines {
+		if matches := sectionHeaderRegex.FindStringSubmatch(line); matches != nil {
+			section = strings.TrimSpace(matches[1])
+			continue
+		}
+		if section != "bumpversion" {
+			continue
+		}
+		if matches := currentVersionRegex.FindStringSubmatch(line); matches != nil {
+			lines[i] = matches[1] + newVersion + matches[2]
+			return strings.Join(lines, ""), nil
+		}
+	}
+	return "", fmt.Errorf("current_version not found in the [bumpversion] section of %s", bump_cfg)
 }
 
 func GetCommitOption() (bool, error) {
-	config, err := ini.Load(bump_cfg)
+	config, err := loadBumpConfig()
 	if err != nil {
 		return false, err
 	}
@@ -147,7 +316,7 @@ func GetCommitOption() (bool, error) {
 }
 
 func GetTagOption() (bool, error) {
-	config, err := ini.Load(bump_cfg)
+	config, err := loadBumpConfig()
 	if err != nil {
 		return false, err
 	}
@@ -162,40 +331,39 @@ func CommitChanges(currentVersion, newVersion, commitMessage string) error {
 	return cmd.Run()
 }
 
-func CreateTag(version, message string) error {
-	config, err := ini.Load(bump_cfg)
+// GetTagName returns the name of the tag for the version with tag_format
+func GetTagName(version string) (string, error) {
+	config, err := loadBumpConfig()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	tagFormat := config.Section("bumpversion").Key("tag_format").String()
-	tagName := strings.ReplaceAll(tagFormat, "{tag}", version)
-
-	cmd := exec.Command("git", "tag", "-a", tagName, "-m", fmt.Sprintf("Version %s", version))
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return strings.ReplaceAll(tagFormat, "{tag}", version), nil
 }
 
-func parseInt(str string) int {
-	i, err := strconv.Atoi(str)
+// CreateTag creates the annotated tag of the bump, signed when sign_tags is
+// set
+func CreateTag(tagName, message string) error {
+	config, err := loadBumpConfig()
 	if err != nil {
-		return 0
+		return err
 	}
-	return i
-}
 
-func checkGitDirectoryStatus() error {
-	// Check if there are unstaged changes
-	cmd := exec.Command("git", "diff", "--exit-code")
-	err := cmd.Run()
-	if err != nil {
-		// If there are unstaged changes, return an error
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-			return fmt.Errorf("git directory has unstaged changes")
+	sign := false
+	if config.Section("bumpversion").HasKey("sign_tags") {
+		sign, err = config.Section("bumpversion").Key("sign_tags").Bool()
+		if err != nil {
+			return fmt.Errorf("invalid sign_tags value: %w", err)
 		}
-		return err
+	}
+	tagType := "-a"
+	if sign {
+		tagType = "-s"
 	}
 
-	return nil
+	cmd := exec.Command("git", "tag", tagType, tagName, "-m", message)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
 }
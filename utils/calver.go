@@ -0,0 +1,165 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultCalVerFormat = "YYYY.MM.MICRO"
+
+const calverMicro = "MICRO"
+
+// Calendar Versioning tokens, see https://calver.org. Longer tokens come
+// first so that YYYY isn't read as YY twice.
+var calverTokens = []string{"YYYY", calverMicro, "YY", "0Y", "MM", "0M", "WW", "0W", "DD", "0D"}
+
+var calverTokenPatterns = map[string]string{
+	"YYYY":      `\d{4}`,
+	"YY":        `\d{1,3}`,
+	"0Y":        `\d{2,3}`,
+	"MM":        `\d{1,2}`,
+	"0M":        `\d{2}`,
+	"WW":        `\d{1,2}`,
+	"0W":        `\d{2}`,
+	"DD":        `\d{1,2}`,
+	"0D":        `\d{2}`,
+	calverMicro: `\d+`,
+}
+
+// CalVerFormat is a calendar version format such as YYYY.MM.MICRO
+type CalVerFormat struct {
+	format string
+	// segments are the tokens and literal characters of the format
+	segments []string
+	// tokens are the tokens of the format, in order
+	tokens []string
+	regex  *regexp.Regexp
+}
+
+// ParseCalVerFormat parses a format made of calendar tokens and literal text
+func ParseCalVerFormat(format string) (*CalVerFormat, error) {
+	f := &CalVerFormat{format: format}
+	pattern := "^"
+	hasDate := false
+
+	for rest := format; rest != ""; {
+		segment := rest[:1]
+		for _, token := range calverTokens {
+			if strings.HasPrefix(rest, token) {
+				segment = token
+				break
+			}
+		}
+		f.segments = append(f.segments, segment)
+		rest = rest[len(segment):]
+
+		tokenPattern, isToken := calverTokenPatterns[segment]
+		if !isToken {
+			pattern += regexp.QuoteMeta(segment)
+			continue
+		}
+		if segment == calverMicro && indexOf(f.tokens, calverMicro) != -1 {
+			return nil, fmt.Errorf("calver format '%s' uses MICRO more than once", format)
+		}
+		if segment != calverMicro {
+			hasDate = true
+		}
+		f.tokens = append(f.tokens, segment)
+		pattern += "(" + tokenPattern + ")"
+	}
+
+	if !hasDate {
+		return nil, fmt.Errorf("calver format '%s' has no date token such as YYYY, MM or DD", format)
+	}
+	f.regex = regexp.MustCompile(pattern + "$")
+	return f, nil
+}
+
+// Next returns the version following the current one on the given date.
+// The micro counter is incremented when the date part is unchanged, and
+// reset to 0 otherwise.
+func (f *CalVerFormat) Next(version string, now time.Time) (string, error) {
+	matches := f.regex.FindStringSubmatch(version)
+	if matches == nil {
+		return "", fmt.Errorf("version '%s' doesn't match the calver format '%s'", version, f.format)
+	}
+
+	values := make(map[string]string)
+	sameDate := true
+	micro := 0
+	for i, token := range f.tokens {
+		current, _ := strconv.Atoi(matches[i+1])
+		if token == calverMicro {
+			micro = current + 1
+			continue
+		}
+
+		today := calverDateValue(token, now)
+		if sameDate && current > today {
+			return "", fmt.Errorf("version '%s' is later than today's date", version)
+		}
+		if current != today {
+			sameDate = false
+		}
+		values[token] = formatCalVerToken(token, today)
+	}
+
+	if !sameDate {
+		micro = 0
+	} else if indexOf(f.tokens, calverMicro) == -1 {
+		return "", fmt.Errorf("version '%s' is already today's version and the calver format '%s' has no MICRO counter", version, f.format)
+	}
+	values[calverMicro] = strconv.Itoa(micro)
+
+	var next strings.Builder
+	for _, segment := range f.segments {
+		if value, isToken := values[segment]; isToken {
+			next.WriteString(value)
+		} else {
+			next.WriteString(segment)
+		}
+	}
+	return next.String(), nil
+}
+
+// calverDateValue returns the numeric value of a date token for the date
+func calverDateValue(token string, date time.Time) int {
+	switch token {
+	case "YYYY":
+		return date.Year()
+	case "YY", "0Y":
+		return date.Year() - 2000
+	case "MM", "0M":
+		return int(date.Month())
+	case "WW", "0W":
+		// Week of the year starting on Monday, like strftime's %W
+		return (date.YearDay() + 6 - (int(date.Weekday())+6)%7) / 7
+	default:
+		return date.Day()
+	}
+}
+
+func formatCalVerToken(token string, value int) string {
+	if strings.HasPrefix(token, "0") {
+		return fmt.Sprintf("%02d", value)
+	}
+	return strconv.Itoa(value)
+}
@@ -34,3 +34,26 @@ func ColorizeDiffStat(output string) string {
 
 	return output
 }
+
+func ColorizeDiff(diff string) string {
+	headerColor := color.New(color.Bold).SprintFunc()
+	hunkColor := color.New(color.FgCyan).SprintFunc()
+	addedColor := color.New(color.FgGreen).SprintFunc()
+	removedColor := color.New(color.FgRed).SprintFunc()
+
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			lines[i] = headerColor(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = hunkColor(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = addedColor(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = removedColor(line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -6,6 +6,17 @@ type Config struct {
 	Tags struct {
 		Messages map[string]string `json:"messages"`
 	} `json:"tags"`
-	Version     string    `json:"version"`
-	LastUpdated time.Time `json:"lastUpdated"`
+	Grep struct {
+		Queries map[string]GrepQuery `json:"queries"`
+	} `json:"grep"`
+	Hosts       map[string]HostConfig `json:"hosts,omitempty"`
+	Version     string                `json:"version"`
+	LastUpdated time.Time             `json:"lastUpdated"`
+}
+
+type GrepQuery struct {
+	Pattern string   `json:"pattern"`
+	Flags   []string `json:"flags,omitempty"`
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
 }
@@ -0,0 +1,61 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"change", "a\nb\nc\n", "a\nB\nc\n", "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{"remove final newline", "t\n", "t", "@@ -1 +1 @@\n-t\n+t\n\\ No newline at end of file\n"},
+		{"add final newline", "t", "t\n", "@@ -1 +1 @@\n-t\n\\ No newline at end of file\n+t\n"},
+		{
+			"change last line without newline", "a\nb", "a\nc",
+			"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+		{"context without newline", "a\nb\nc", "A\nb\nc", "@@ -1,3 +1,3 @@\n-a\n+A\n b\n c\n\\ No newline at end of file\n"},
+		{"new file", "", "a\nb\n", "@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"deleted content", "a\n", "", "@@ -1 +0,0 @@\n-a\n"},
+		{"insert at start", "b\nc\n", "a\nb\nc\n", "@@ -1,2 +1,3 @@\n+a\n b\n c\n"},
+		{
+			"hunks sharing context are merged",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			"1\nX\n3\n4\n5\n6\n7\n8\nY\n10\n",
+			"@@ -1,10 +1,10 @@\n 1\n-2\n+X\n 3\n 4\n 5\n 6\n 7\n 8\n-9\n+Y\n 10\n",
+		},
+		{
+			"distant hunks are split",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			"1\nX\n3\n4\n5\n6\n7\n8\n9\nY\n11\n",
+			"@@ -1,5 +1,5 @@\n 1\n-2\n+X\n 3\n 4\n 5\n@@ -7,5 +7,5 @@\n 7\n 8\n 9\n-10\n+Y\n 11\n",
+		},
+	}
+
+	for _, test := range tests {
+		want := test.want
+		if want != "" {
+			want = "--- a/f\n+++ b/f\n" + want
+		}
+		if got := UnifiedDiff("f", test.old, test.new, 3); got != want {
+			t.Errorf("%s: UnifiedDiff() =\n%s\nwant\n%s", test.name, got, want)
+		}
+	}
+}
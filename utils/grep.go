@@ -0,0 +1,166 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type GrepMatch struct {
+	Repo   string   `json:"repo"`
+	File   string   `json:"file"`
+	Line   int      `json:"line"`
+	Column int      `json:"column"`
+	Match  string   `json:"match"`
+	Text   string   `json:"text"`
+	Before []string `json:"before"`
+	After  []string `json:"after"`
+}
+
+// Path returns the full path of the matched file
+func (m GrepMatch) Path() string {
+	return filepath.Join(m.Repo, m.File)
+}
+
+// grepShortFlags and grepLongFlags are the git grep flags allowed in saved
+// queries. They only change how the pattern matches, flags changing the
+// output format would break the parsing of the matches.
+var (
+	grepShortFlags = "iEGPFwaI"
+	grepLongFlags  = []string{
+		"--ignore-case", "--extended-regexp", "--basic-regexp", "--perl-regexp",
+		"--fixed-strings", "--word-regexp", "--text", "--untracked",
+	}
+)
+
+// ValidateFlags returns an error if a flag of the query isn't allowed in
+// saved queries. Short flags can be combined, e.g. -iE.
+func (q GrepQuery) ValidateFlags() error {
+	for _, flag := range q.Flags {
+		switch {
+		case strings.HasPrefix(flag, "--"):
+			if indexOf(grepLongFlags, flag) != -1 {
+				continue
+			}
+		case strings.HasPrefix(flag, "-") && len(flag) > 1:
+			if strings.Trim(flag[1:], grepShortFlags) == "" {
+				continue
+			}
+		}
+		return fmt.Errorf("unsupported flag '%s', expected one of: -%s, %s", flag,
+			strings.Join(strings.Split(grepShortFlags, ""), ", -"), strings.Join(grepLongFlags, ", "))
+	}
+	return nil
+}
+
+// GitGrep runs git grep for the query inside the repository and returns
+// one match per occurrence, with up to context lines before and after it.
+func GitGrep(repo string, query GrepQuery, context int) ([]GrepMatch, error) {
+	args := []string{"grep", "-n", "--column", "-o", "-z"}
+	args = append(args, query.Flags...)
+	args = append(args, "-e", query.Pattern, "--")
+	args = append(args, query.Include...)
+	for _, pattern := range query.Exclude {
+		args = append(args, ":(exclude)"+pattern)
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo
+	output, err := cmd.Output()
+	if err != nil {
+		// Ignore "exit status 1" error when no matches are found
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var matches []GrepMatch
+	fileLines := make(map[string][]string)
+	// lineOffsets is the end of the previous match on each line, as git grep
+	// -o --column reports the column of the first match for every match
+	lineOffsets := make(map[string]int)
+
+	for _, line := range strings.Split(string(output), "\n") {
+		if line == "" {
+			continue
+		}
+
+		// file, line number, column and the matched text are NUL separated
+		parts := strings.SplitN(line, "\x00", 4)
+		if len(parts) != 4 {
+			continue
+		}
+
+		lineNumber, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid line number in git grep output: %s", parts[1])
+		}
+		column, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid column in git grep output: %s", parts[2])
+		}
+
+		lines, ok := fileLines[parts[0]]
+		if !ok {
+			data, err := os.ReadFile(filepath.Join(repo, parts[0]))
+			if err != nil {
+				return nil, err
+			}
+			lines = strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+			fileLines[parts[0]] = lines
+		}
+
+		match := GrepMatch{
+			Repo:   repo,
+			File:   parts[0],
+			Line:   lineNumber,
+			Column: column,
+			Match:  parts[3],
+			Before: []string{},
+			After:  []string{},
+		}
+		if lineNumber <= len(lines) {
+			match.Text = lines[lineNumber-1]
+
+			key := parts[0] + "\x00" + parts[1]
+			offset := lineOffsets[key]
+			if i := strings.Index(match.Text[offset:], match.Match); i != -1 {
+				match.Column = offset + i + 1
+				lineOffsets[key] = offset + i + len(match.Match)
+			}
+			start := lineNumber - 1 - context
+			if start < 0 {
+				start = 0
+			}
+			end := lineNumber + context
+			if end > len(lines) {
+				end = len(lines)
+			}
+			match.Before = append(match.Before, lines[start:lineNumber-1]...)
+			match.After = append(match.After, lines[lineNumber:end]...)
+		}
+
+		matches = append(matches, match)
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestGrepQueryValidateFlags(t *testing.T) {
+	tests := []struct {
+		flags []string
+		valid bool
+	}{
+		{nil, true},
+		{[]string{"-i", "-E"}, true},
+		{[]string{"-iwP"}, true},
+		{[]string{"--fixed-strings", "--untracked"}, true},
+		{[]string{"-l"}, false},
+		{[]string{"-v"}, false},
+		{[]string{"-il"}, false},
+		{[]string{"--count"}, false},
+		{[]string{"-"}, false},
+		{[]string{"foo"}, false},
+	}
+
+	for _, test := range tests {
+		err := GrepQuery{Pattern: "foo", Flags: test.flags}.ValidateFlags()
+		if (err == nil) != test.valid {
+			t.Errorf("ValidateFlags(%q) returned %v, want valid = %t", test.flags, err, test.valid)
+		}
+	}
+}
+
+func TestGitGrepColumns(t *testing.T) {
+	repo := t.TempDir()
+	data := "foo bar foo\nbar\n  foo foofoo\n"
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "a.txt"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", args[0], err, output)
+		}
+	}
+
+	matches, err := GitGrep(repo, GrepQuery{Pattern: "foo"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][2]int{{1, 1}, {1, 9}, {3, 3}, {3, 7}, {3, 10}}
+	if len(matches) != len(want) {
+		t.Fatalf("GitGrep returned %d matches, want %d: %+v", len(matches), len(want), matches)
+	}
+	for i, match := range matches {
+		if match.Line != want[i][0] || match.Column != want[i][1] {
+			t.Errorf("match %d at %d:%d, want %d:%d", i, match.Line, match.Column, want[i][0], want[i][1])
+		}
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/arzkar/git-utils/giturl"
+)
+
+// Supported hosting providers
+const (
+	HostGitHub    = "github"
+	HostGitLab    = "gitlab"
+	HostBitbucket = "bitbucket"
+	HostGitea     = "gitea"
+	HostAzure     = "azure"
+)
+
+type HostConfig struct {
+	Type    string `json:"type"`
+	BaseURL string `json:"base_url,omitempty"`
+}
+
+type hostingLinks struct {
+	Host       string
+	RepoURL    string
+	CompareURL string
+	TagURL     string
+}
+
+// createHostingVariables returns the host, repo_url, compare_url and tag_url
+// template variables for the remote. compare_url is empty when there is no
+// previous tag.
+func createHostingVariables(remote *giturl.URL, prevTag, newTag string) map[string]string {
+	variables := map[string]string{
+		"host":        remote.Host,
+		"repo_url":    "",
+		"compare_url": "",
+		"tag_url":     "",
+	}
+
+	// Local remotes have no web UI
+	if remote.Scheme == "file" {
+		return variables
+	}
+
+	// Self-hosted instances are configured in the app config
+	var hosts map[string]HostConfig
+	config, err := ReadConfigFile()
+	if err == nil {
+		hosts = config.Hosts
+	}
+
+	links, err := getHostingLinks(remote, prevTag, newTag, hosts)
+	if err != nil {
+		return variables
+	}
+
+	variables["host"] = links.Host
+	variables["repo_url"] = links.RepoURL
+	variables["compare_url"] = links.CompareURL
+	variables["tag_url"] = links.TagURL
+	return variables
+}
+
+func getHostingLinks(remote *giturl.URL, prevTag, newTag string, hosts map[string]HostConfig) (hostingLinks, error) {
+	host, path := remote.Host, remote.Path
+
+	hostConfig, ok := hosts[host]
+	if !ok {
+		hostConfig = HostConfig{Type: detectHostType(host)}
+	}
+	baseURL := strings.TrimSuffix(hostConfig.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://" + host
+	}
+
+	links := hostingLinks{Host: host}
+	switch hostConfig.Type {
+	case HostGitLab:
+		links.RepoURL = baseURL + "/" + path
+		links.CompareURL = fmt.Sprintf("%s/-/compare/%s...%s", links.RepoURL, prevTag, newTag)
+		links.TagURL = fmt.Sprintf("%s/-/tags/%s", links.RepoURL, newTag)
+	case HostBitbucket:
+		links.RepoURL = baseURL + "/" + path
+		links.CompareURL = fmt.Sprintf("%s/branches/compare/%s%%0D%s", links.RepoURL, newTag, prevTag)
+		links.TagURL = fmt.Sprintf("%s/src/%s", links.RepoURL, newTag)
+	case HostAzure:
+		// The remote path is org/project/repo, while the web UI lives at
+		// dev.azure.com/org/project/_git/repo
+		if remote.Namespace == "" {
+			return hostingLinks{}, fmt.Errorf("invalid Azure DevOps remote path: %s", path)
+		}
+		if hostConfig.BaseURL == "" && strings.HasSuffix(host, "dev.azure.com") {
+			baseURL = "https://dev.azure.com"
+			links.Host = "dev.azure.com"
+		}
+		links.RepoURL = fmt.Sprintf("%s/%s/_git/%s", baseURL, remote.Namespace, remote.Name)
+		links.CompareURL = fmt.Sprintf("%s/branchCompare?baseVersion=GT%s&targetVersion=GT%s", links.RepoURL, url.QueryEscape(prevTag), url.QueryEscape(newTag))
+		links.TagURL = fmt.Sprintf("%s?version=GT%s", links.RepoURL, url.QueryEscape(newTag))
+	case HostGitHub, HostGitea:
+		links.RepoURL = baseURL + "/" + path
+		links.CompareURL = fmt.Sprintf("%s/compare/%s...%s", links.RepoURL, prevTag, newTag)
+		links.TagURL = fmt.Sprintf("%s/releases/tag/%s", links.RepoURL, newTag)
+	default:
+		return hostingLinks{}, fmt.Errorf("unknown host type '%s' for host '%s'", hostConfig.Type, host)
+	}
+
+	if prevTag == "" {
+		links.CompareURL = ""
+	}
+
+	return links, nil
+}
+
+func detectHostType(host string) string {
+	switch {
+	case strings.Contains(host, "gitlab"):
+		return HostGitLab
+	case strings.Contains(host, "bitbucket"):
+		return HostBitbucket
+	case strings.HasSuffix(host, "dev.azure.com"), strings.HasSuffix(host, "visualstudio.com"):
+		return HostAzure
+	case strings.Contains(host, "gitea"), strings.Contains(host, "forgejo"), host == "codeberg.org":
+		return HostGitea
+	default:
+		return HostGitHub
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Conventional Commits header, e.g. "feat(api)!: remove endpoint"
+var conventionalCommitRegex = regexp.MustCompile(`^(\w+)(?:\([^)]*\))?(!)?:`)
+
+// GetNextTag returns the name of the tag following the latest semver tag,
+// bumping the given part. The "auto" part is chosen from the Conventional
+// Commits since the latest tag.
+func GetNextTag(dir, part string, options PreviousTagOptions) (string, error) {
+	options.Strategy = PreviousTagSemver
+	if options.Pattern == "" {
+		options.Pattern = "*"
+	}
+
+	latestTag, err := GetPreviousTag(dir, "", options)
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the prefix of the existing tags, e.g. "service-a/v"
+	prefix := tagPatternPrefix(options.Pattern)
+	var version Version
+	if latestTag == "" {
+		if !strings.HasSuffix(prefix, "v") {
+			prefix += "v"
+		}
+	} else {
+		if strings.HasPrefix(strings.TrimPrefix(latestTag, prefix), "v") {
+			prefix += "v"
+		}
+		version, err = ParseVersion(strings.TrimPrefix(latestTag, prefix))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if part == "auto" {
+		part, err = detectVersionBump(dir, latestTag)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	next, err := version.Bump(part)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + next.String(), nil
+}
+
+// detectVersionBump returns "major" if a commit since the tag has a breaking
+// change, "minor" if one is a feature and "patch" otherwise
+func detectVersionBump(dir, tag string) (string, error) {
+	revisionRange := "HEAD"
+	if tag != "" {
+		revisionRange = tag + "..HEAD"
+	}
+
+	cmd := exec.Command("git", "-C", dir, "log", "--format=%B%x00", revisionRange)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the commits since '%s': %w", tag, err)
+	}
+
+	part := "patch"
+	for _, message := range strings.Split(string(output), "\x00") {
+		message = strings.TrimSpace(message)
+		if message == "" {
+			continue
+		}
+
+		header := conventionalCommitRegex.FindStringSubmatch(message)
+		if header != nil && header[2] == "!" ||
+			strings.Contains(message, "BREAKING CHANGE:") || strings.Contains(message, "BREAKING-CHANGE:") {
+			return "major", nil
+		}
+		if header != nil && header[1] == "feat" {
+			part = "minor"
+		}
+	}
+
+	return part, nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Previous tag resolution strategies
+const (
+	// PreviousTagAncestry picks the nearest tag reachable from HEAD
+	PreviousTagAncestry = "ancestry"
+	// PreviousTagSemver picks the highest semantic version below the new tag
+	PreviousTagSemver = "semver"
+)
+
+type PreviousTagOptions struct {
+	Strategy string
+	// Pattern limits the candidates to tags matching a glob such as
+	// "service-a/v*". The part before the first wildcard is the tag prefix.
+	Pattern           string
+	IncludePrerelease bool
+}
+
+// GetPreviousTag returns the tag preceding newTag in the repository, or an
+// empty string if there is none
+func GetPreviousTag(dir, newTag string, options PreviousTagOptions) (string, error) {
+	if options.Pattern == "" {
+		options.Pattern = "*"
+	}
+
+	switch options.Strategy {
+	case "", PreviousTagAncestry:
+		return getPreviousTagByAncestry(dir, newTag, options)
+	case PreviousTagSemver:
+		return getPreviousTagBySemver(dir, newTag, options)
+	default:
+		return "", fmt.Errorf("unknown previous tag strategy '%s', use '%s' or '%s'", options.Strategy, PreviousTagAncestry, PreviousTagSemver)
+	}
+}
+
+func getPreviousTagByAncestry(dir, newTag string, options PreviousTagOptions) (string, error) {
+	args := []string{"-C", dir, "describe", "--abbrev=0", "--tags", "--match", options.Pattern}
+	if !options.IncludePrerelease {
+		args = append(args, "--exclude", tagPatternPrefix(options.Pattern)+"*-*")
+	}
+	if newTag != "" {
+		args = append(args, "--exclude", newTag)
+	}
+
+	cmd := exec.Command("git", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		// describe fails when no tag can be found
+		if strings.Contains(string(output), "No names found") || strings.Contains(string(output), "No tags can describe") {
+			return "", nil
+		}
+		return "", fmt.Errorf("%s: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+func getPreviousTagBySemver(dir, newTag string, options PreviousTagOptions) (string, error) {
+	cmd := exec.Command("git", "-C", dir, "tag", "--list", options.Pattern)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	prefix := tagPatternPrefix(options.Pattern)
+	newVersion, newErr := parseTagVersion(newTag, prefix)
+
+	prevTag := ""
+	var prevVersion Version
+	for _, tag := range strings.Fields(string(output)) {
+		if tag == newTag {
+			continue
+		}
+
+		version, err := parseTagVersion(tag, prefix)
+		if err != nil {
+			continue
+		}
+		if version.IsPrerelease() && !options.IncludePrerelease {
+			continue
+		}
+		// Only versions below the new tag can precede it
+		if newErr == nil && version.Compare(newVersion) >= 0 {
+			continue
+		}
+
+		if prevTag == "" || version.Compare(prevVersion) > 0 {
+			prevTag, prevVersion = tag, version
+		}
+	}
+
+	return prevTag, nil
+}
+
+// parseTagVersion parses tags like service-a/v1.2.3 with the given prefix
+func parseTagVersion(tag, prefix string) (Version, error) {
+	if !strings.HasPrefix(tag, prefix) {
+		return Version{}, fmt.Errorf("tag '%s' doesn't start with '%s'", tag, prefix)
+	}
+	return ParseVersion(strings.TrimPrefix(strings.TrimPrefix(tag, prefix), "v"))
+}
+
+// tagPatternPrefix returns the literal part of a glob before any wildcard
+func tagPatternPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, "*?["); i != -1 {
+		return pattern[:i]
+	}
+	return pattern
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const tagsRefPrefix = "refs/tags/"
+
+// GetLocalTags returns the tags of the repository sorted by name
+func GetLocalTags(dir string) ([]string, error) {
+	cmd := exec.Command("git", "-C", dir, "tag", "--list")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags in repository '%s': %w", dir, err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// GetRemoteTags returns the set of tags on the remote
+func GetRemoteTags(dir, remote string) (map[string]bool, error) {
+	cmd := exec.Command("git", "-C", dir, "ls-remote", "--tags", remote)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags on remote '%s' of repository '%s': %s\n%s", remote, dir, err, string(output))
+	}
+
+	tags := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], tagsRefPrefix) {
+			continue
+		}
+		// Annotated tags are listed twice, once peeled with a ^{} suffix
+		tags[strings.TrimSuffix(strings.TrimPrefix(fields[1], tagsRefPrefix), "^{}")] = true
+	}
+	return tags, nil
+}
+
+// PushTag pushes a single tag to the remote
+func PushTag(dir, remote, tag string) error {
+	cmd := exec.Command("git", "-C", dir, "push", remote, tagsRefPrefix+tag)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to push tag '%s' in repository '%s': %s\n%s", tag, dir, err, string(output))
+	}
+	return nil
+}
+
+// DeleteLocalTag deletes the tag from the repository
+func DeleteLocalTag(dir, tag string) error {
+	cmd := exec.Command("git", "-C", dir, "tag", "--delete", tag)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to delete tag '%s' in repository '%s': %s\n%s", tag, dir, err, string(output))
+	}
+	return nil
+}
+
+// DeleteRemoteTag deletes the tag from the remote
+func DeleteRemoteTag(dir, remote, tag string) error {
+	cmd := exec.Command("git", "-C", dir, "push", remote, "--delete", tagsRefPrefix+tag)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to delete tag '%s' on remote '%s' of repository '%s': %s\n%s", tag, remote, dir, err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type FileReplacement struct {
+	Repo    string
+	File    string
+	Count   int
+	OldData string
+	NewData string
+}
+
+// Path returns the full path of the file to rewrite
+func (r FileReplacement) Path() string {
+	return filepath.Join(r.Repo, r.File)
+}
+
+// Diff returns the unified diff of the replacement
+func (r FileReplacement) Diff() string {
+	return UnifiedDiff(filepath.ToSlash(r.File), r.OldData, r.NewData, 3)
+}
+
+// Apply writes the new contents of the file, keeping its permissions.
+// Symlinks and other files that aren't regular files are left untouched.
+func (r FileReplacement) Apply() error {
+	info, err := os.Lstat(r.Path())
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	return os.WriteFile(r.Path(), []byte(r.NewData), info.Mode().Perm())
+}
+
+// FindReplacements computes the new contents of every tracked file in the
+// repository that matches re, limited to the include and exclude pathspecs.
+// Submodules, symlinks and other files that aren't regular files are
+// skipped. The replacement may reference capture groups as $1 or ${name}.
+func FindReplacements(repo string, re *regexp.Regexp, replacement string, include, exclude []string) ([]FileReplacement, error) {
+	args := []string{"-C", repo, "ls-files", "-z", "-s", "--"}
+	args = append(args, include...)
+	for _, pattern := range exclude {
+		args = append(args, ":(exclude)"+pattern)
+	}
+
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in repository '%s': %w", repo, err)
+	}
+
+	var replacements []FileReplacement
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(string(output), "\x00") {
+		// Each entry is "<mode> <object> <stage>\t<file>"
+		fields := strings.SplitN(entry, "\t", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		mode, file := strings.Fields(fields[0])[0], fields[1]
+		// Only regular files, not symlinks (120000) or submodules (160000).
+		// Conflicted files are listed once per stage.
+		if (mode != "100644" && mode != "100755") || seen[file] {
+			continue
+		}
+		seen[file] = true
+
+		path := filepath.Join(repo, file)
+		info, err := os.Lstat(path)
+		if err != nil {
+			// Tracked files deleted from the working tree are skipped
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		// The working tree may differ from the index
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		// Skip binary files
+		if bytes.IndexByte(data, 0) != -1 {
+			continue
+		}
+
+		count := len(re.FindAllIndex(data, -1))
+		if count == 0 {
+			continue
+		}
+
+		newData := re.ReplaceAllString(string(data), replacement)
+		if newData == string(data) {
+			continue
+		}
+
+		replacements = append(replacements, FileReplacement{
+			Repo:    repo,
+			File:    file,
+			Count:   count,
+			OldData: string(data),
+			NewData: newData,
+		})
+	}
+
+	return replacements, nil
+}
+
+// StageFiles stages the given files in the repository
+func StageFiles(repo string, files []string) error {
+	args := append([]string{"-C", repo, "add", "--"}, files...)
+	cmd := exec.Command("git", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to stage files in repository '%s': %s\n%s", repo, err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Repository-local config file, committed in the repository
+const repoConfigFile = ".git-utils.json"
+
+type RepoConfig struct {
+	Tags struct {
+		Messages map[string]string `json:"messages"`
+	} `json:"tags"`
+}
+
+// ReadRepoConfigFile reads the config file at the root of the repository
+// containing dir. A missing file results in an empty config.
+func ReadRepoConfigFile(dir string) (RepoConfig, error) {
+	config := RepoConfig{}
+
+	cmd := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel")
+	output, err := cmd.Output()
+	if err != nil {
+		return config, fmt.Errorf("failed to find the repository root: %w", err)
+	}
+
+	filePath := filepath.Join(strings.TrimSpace(string(output)), repoConfigFile)
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return config, err
+	}
+
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("invalid %s: %w", filePath, err)
+	}
+
+	return config, nil
+}
+
+// GetTagMessages returns the tag message templates of the app config merged
+// with the ones of the repository, which take precedence
+func GetTagMessages(dir string) (map[string]string, error) {
+	config, err := ReadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	repoConfig, err := ReadRepoConfigFile(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make(map[string]string)
+	for keyword, message := range config.Tags.Messages {
+		messages[keyword] = message
+	}
+	for keyword, message := range repoConfig.Tags.Messages {
+		messages[keyword] = message
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WalkRepositories recursively walks dir and calls fn for every git
+// repository found, including repositories nested inside other ones.
+func WalkRepositories(dir string, fn func(path string) error) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Only process directories
+		if !d.IsDir() {
+			return nil
+		}
+
+		// Never descend into the git metadata itself
+		if d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		if !IsGitRepository(path) {
+			return nil
+		}
+
+		return fn(path)
+	})
+}
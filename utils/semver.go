@@ -0,0 +1,226 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Semantic Versioning 2.0.0, see https://semver.org
+var semverRegex = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string
+	Build      []string
+}
+
+// ParseVersion parses a semantic version such as 1.2.3-rc.1+build.5
+func ParseVersion(version string) (Version, error) {
+	matches := semverRegex.FindStringSubmatch(version)
+	if matches == nil {
+		return Version{}, fmt.Errorf("invalid semantic version: '%s'", version)
+	}
+
+	var v Version
+	var err error
+	for i, part := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		*part, err = strconv.Atoi(matches[i+1])
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid semantic version: '%s': %w", version, err)
+		}
+	}
+	if matches[4] != "" {
+		v.Prerelease = strings.Split(matches[4], ".")
+	}
+	if matches[5] != "" {
+		v.Build = strings.Split(matches[5], ".")
+	}
+
+	return v, nil
+}
+
+// IsPrerelease reports whether the version has prerelease identifiers
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+	return s
+}
+
+// Bump returns the next version for the part ("major", "minor" or "patch"),
+// dropping any prerelease and build metadata. Like npm, a pre-release that
+// already targets the part is released instead: 1.2.3-rc.1 patch is 1.2.3
+// and 1.3.0-rc.1 minor is 1.3.0, while 1.2.3-rc.1 minor is 1.3.0.
+func (v Version) Bump(part string) (Version, error) {
+	release := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	switch part {
+	case "major":
+		if v.IsPrerelease() && v.Minor == 0 && v.Patch == 0 {
+			return release, nil
+		}
+		return Version{Major: v.Major + 1}, nil
+	case "minor":
+		if v.IsPrerelease() && v.Patch == 0 {
+			return release, nil
+		}
+		return Version{Major: v.Major, Minor: v.Minor + 1}, nil
+	case "patch":
+		if v.IsPrerelease() {
+			return release, nil
+		}
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}, nil
+	default:
+		return Version{}, fmt.Errorf("unknown version part '%s', use 'major', 'minor' or 'patch'", part)
+	}
+}
+
+// WithPrerelease returns the version with a "<label>.1" pre-release
+func (v Version) WithPrerelease(label string) Version {
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch, Prerelease: []string{label, "1"}}
+}
+
+// BumpPrerelease moves a "<label>.<n>" pre-release to the given label, which
+// must not come before the current one in labels. The number is incremented
+// when the label stays the same. A release version gets its patch bumped.
+func (v Version) BumpPrerelease(label string, labels []string) (Version, error) {
+	target := indexOf(labels, label)
+	if target == -1 {
+		return Version{}, fmt.Errorf("unknown pre-release label '%s', expected one of: %s", label, strings.Join(labels, ", "))
+	}
+
+	if !v.IsPrerelease() {
+		next, _ := v.Bump("patch")
+		return next.WithPrerelease(label), nil
+	}
+
+	current, number, err := v.prereleaseParts()
+	if err != nil {
+		return Version{}, err
+	}
+
+	switch position := indexOf(labels, current); {
+	case position == -1:
+		return Version{}, fmt.Errorf("unknown pre-release label '%s' in version %s, expected one of: %s", current, v, strings.Join(labels, ", "))
+	case target < position:
+		return Version{}, fmt.Errorf("can't go back from pre-release '%s' to '%s'", current, label)
+	case target > position:
+		return v.WithPrerelease(label), nil
+	default:
+		next := v.WithPrerelease(label)
+		next.Prerelease[1] = strconv.Itoa(number + 1)
+		return next, nil
+	}
+}
+
+// Release drops the pre-release and build metadata
+func (v Version) Release() (Version, error) {
+	if !v.IsPrerelease() {
+		return Version{}, fmt.Errorf("version %s is not a pre-release", v)
+	}
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}, nil
+}
+
+// prereleaseParts splits a "<label>.<n>" pre-release
+func (v Version) prereleaseParts() (string, int, error) {
+	if len(v.Prerelease) != 2 {
+		return "", 0, fmt.Errorf("unsupported pre-release in version %s, expected <label>.<number> such as rc.1", v)
+	}
+	number, err := strconv.Atoi(v.Prerelease[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("unsupported pre-release in version %s, expected <label>.<number> such as rc.1", v)
+	}
+	return v.Prerelease[0], number, nil
+}
+
+// Compare returns -1, 0 or 1 if v has lower, equal or higher precedence
+// than other. Build metadata is ignored.
+func (v Version) Compare(other Version) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if diff != 0 {
+			return sign(diff)
+		}
+	}
+
+	// A version without prerelease identifiers has higher precedence
+	switch {
+	case len(v.Prerelease) == 0 && len(other.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(other.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(other.Prerelease); i++ {
+		if c := compareIdentifiers(v.Prerelease[i], other.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(v.Prerelease) - len(other.Prerelease))
+}
+
+// compareIdentifiers compares prerelease identifiers, numeric identifiers
+// have lower precedence than alphanumeric ones
+func compareIdentifiers(a, b string) int {
+	aNum, aErr := strconv.Atoi(a)
+	bNum, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return sign(aNum - bNum)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func indexOf(items []string, item string) int {
+	for i, candidate := range items {
+		if candidate == item {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVersionBump(t *testing.T) {
+	tests := []struct {
+		version string
+		part    string
+		want    string
+	}{
+		{"1.2.3", "patch", "1.2.4"},
+		{"1.2.3", "minor", "1.3.0"},
+		{"1.2.3", "major", "2.0.0"},
+		{"1.2.3+build.5", "patch", "1.2.4"},
+		{"1.2.3-rc.1", "patch", "1.2.3"},
+		{"1.2.3-rc.1", "minor", "1.3.0"},
+		{"1.2.3-rc.1", "major", "2.0.0"},
+		{"1.1.0-rc.1", "patch", "1.1.0"},
+		{"1.1.0-rc.1", "minor", "1.1.0"},
+		{"1.1.0-rc.1", "major", "2.0.0"},
+		{"2.0.0-rc.1", "patch", "2.0.0"},
+		{"2.0.0-rc.1", "minor", "2.0.0"},
+		{"2.0.0-rc.1", "major", "2.0.0"},
+		{"2.0.0-rc.1+build.5", "major", "2.0.0"},
+	}
+
+	for _, test := range tests {
+		v, err := ParseVersion(test.version)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q) returned error: %v", test.version, err)
+		}
+		got, err := v.Bump(test.part)
+		if err != nil {
+			t.Errorf("%s.Bump(%q) returned error: %v", test.version, test.part, err)
+			continue
+		}
+		if got.String() != test.want {
+			t.Errorf("%s.Bump(%q) = %s, want %s", test.version, test.part, got, test.want)
+		}
+	}
+
+	if _, err := (Version{}).Bump("build"); err == nil {
+		t.Errorf("Bump(%q) returned no error", "build")
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    Version
+	}{
+		{"0.0.0", Version{}},
+		{"1.2.3", Version{Major: 1, Minor: 2, Patch: 3}},
+		{"1.2.3-rc.1", Version{Major: 1, Minor: 2, Patch: 3, Prerelease: []string{"rc", "1"}}},
+		{"1.2.3+build.5", Version{Major: 1, Minor: 2, Patch: 3, Build: []string{"build", "5"}}},
+		{"1.2.3-0a.x-y+001", Version{Major: 1, Minor: 2, Patch: 3, Prerelease: []string{"0a", "x-y"}, Build: []string{"001"}}},
+	}
+
+	for _, test := range tests {
+		got, err := ParseVersion(test.version)
+		if err != nil {
+			t.Errorf("ParseVersion(%q) returned error: %v", test.version, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseVersion(%q) = %+v, want %+v", test.version, got, test.want)
+		}
+	}
+}
+
+func TestParseVersionErrors(t *testing.T) {
+	for _, version := range []string{
+		"",
+		"1.2",
+		"v1.2.3",
+		"01.2.3",
+		"1.02.3",
+		"1.2.3-",
+		"1.2.3-rc.01",
+		"1.2.3-rc..1",
+		"1.2.3+",
+		"1.2.3+build..5",
+		"1.2.3.4",
+	} {
+		if _, err := ParseVersion(version); err == nil {
+			t.Errorf("ParseVersion(%q) returned no error", version)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.3.0", "1.2.9", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.2.3+build.1", "1.2.3+build.2", 0},
+		{"1.2.3-rc.1", "1.2.3", -1},
+		{"1.2.3", "1.2.3-rc.1", 1},
+		{"1.2.3-alpha", "1.2.3-alpha.1", -1},
+		{"1.2.3-alpha.1", "1.2.3-alpha.beta", -1},
+		{"1.2.3-alpha.beta", "1.2.3-beta", -1},
+		{"1.2.3-beta.2", "1.2.3-beta.11", -1},
+		{"1.2.3-rc.1", "1.2.3-beta.11", 1},
+	}
+
+	for _, test := range tests {
+		a, err := ParseVersion(test.a)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q) returned error: %v", test.a, err)
+		}
+		b, err := ParseVersion(test.b)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q) returned error: %v", test.b, err)
+		}
+		if got := a.Compare(b); got != test.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
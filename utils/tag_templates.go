@@ -17,65 +17,151 @@ package utils
 
 import (
 	"fmt"
-	"os"
 	"os/exec"
+	"sort"
 	"strings"
+	"text/template"
+	"time"
+
+	"github.com/arzkar/git-utils/giturl"
 )
 
-func CreateTemplateVariables(dir, prevTag, newTag, message string) map[string]string {
+// Helper functions available in Go template tag messages
+var templateFuncs = template.FuncMap{
+	"join":  func(sep string, items []string) string { return strings.Join(items, sep) },
+	"split": func(sep, s string) []string { return strings.Split(s, sep) },
+	"lines": func(s string) []string {
+		if s == "" {
+			return nil
+		}
+		return strings.Split(s, "\n")
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"date":  func(layout string) string { return time.Now().Format(layout) },
+	"default": func(fallback, value string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	},
+}
+
+// ParseTemplate renders a tag message. Messages containing "{{" are Go
+// text/template templates with the variables as fields (e.g. {{.prevTag}}),
+// other messages use the plain {variable} syntax.
+func ParseTemplate(message string, variables map[string]string) (string, error) {
+	if !strings.Contains(message, "{{") {
+		for key, value := range variables {
+			message = strings.ReplaceAll(message, "{"+key+"}", value)
+		}
+		return message, nil
+	}
+
+	tmpl, err := template.New("message").Funcs(templateFuncs).Option("missingkey=zero").Parse(message)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, variables)
+	if err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+
+	return b.String(), nil
+}
+
+func CreateTemplateVariables(dir, prevTag, newTag, message string) (map[string]string, error) {
+	remote, err := getRemoteURL(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commits since the previous tag, or the whole history if there is none
+	revisionRange := "HEAD"
+	if prevTag != "" {
+		revisionRange = prevTag + "..HEAD"
+	}
+
 	templateVariables := map[string]string{
-		"repo_owner": getRepositoryOwner(dir),
-		"repo_name":  getRepositoryName(dir),
-		"prevTag":    prevTag,
-		"newTag":     newTag,
+		"repo_owner":     remote.Owner,
+		"repo_namespace": remote.Namespace,
+		"repo_name":      remote.Name,
+		"prevTag":        prevTag,
+		"newTag":         newTag,
+		"date":           time.Now().Format("2006-01-02"),
+		"author":         getTagger(dir),
+		"commit_count":   gitOutput(dir, "rev-list", "--count", revisionRange),
+		"commits":        gitOutput(dir, "log", "--no-decorate", "--format=%h %s", revisionRange),
+		"contributors":   getContributors(dir, revisionRange),
+		"branch":         gitOutput(dir, "rev-parse", "--abbrev-ref", "HEAD"),
+		"head_sha":       gitOutput(dir, "rev-parse", "HEAD"),
 	}
-	return templateVariables
+	for key, value := range createHostingVariables(remote, prevTag, newTag) {
+		templateVariables[key] = value
+	}
+	return templateVariables, nil
 }
 
-func getRepositoryOwner(dir string) string {
-	cmd := exec.Command("git", "-C", dir, "config", "--get", "remote.origin.url")
+// getRemoteURL returns the parsed URL of the origin remote, with any
+// url.<base>.insteadOf rewrites applied
+func getRemoteURL(dir string) (*giturl.URL, error) {
+	cmd := exec.Command("git", "-C", dir, "ls-remote", "--get-url", "origin")
 	output, err := cmd.Output()
 	if err != nil {
-		fmt.Println("Failed to get remote URL:", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to get remote URL: %w", err)
 	}
 
-	url := strings.TrimSpace(string(output))
-	parts := strings.Split(url, "/")
-
-	remote := parts[len(parts)-2]
-	remote = strings.TrimSuffix(remote, ".git")
+	// git echoes the name back when there is no such remote
+	remoteURL := strings.TrimSpace(string(output))
+	if remoteURL == "origin" {
+		return nil, fmt.Errorf("repository has no 'origin' remote")
+	}
 
-	if len(parts) == 2 {
-		remoteParts := strings.Split(remote, ":")
-		if len(remoteParts) != 2 {
-			fmt.Println("Invalid remote URL format:", remote)
-			os.Exit(1)
-		}
-		return strings.TrimPrefix(remoteParts[1], "git@")
-	} else if len(parts) == 5 {
-		parts = strings.Split(remote, ":")
-		username := parts[0]
-		return username
-
-	} else {
-		fmt.Println("Invalid remote URL:", url)
-		os.Exit(1)
+	remote, err := giturl.Parse(remoteURL)
+	if err != nil {
+		return nil, err
 	}
-	return ""
+	return remote, nil
 }
 
-func getRepositoryName(dir string) string {
-	cmd := exec.Command("git", "-C", dir, "config", "--get", "remote.origin.url")
+// gitOutput returns the trimmed output of the git command, or an empty
+// string if it fails
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
 	output, err := cmd.Output()
 	if err != nil {
-		fmt.Println("Failed to get remote URL:", err)
-		os.Exit(1)
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// getTagger returns the name git uses as the tagger, honoring the
+// GIT_COMMITTER_NAME environment variable
+func getTagger(dir string) string {
+	ident := gitOutput(dir, "var", "GIT_COMMITTER_IDENT")
+	if i := strings.Index(ident, " <"); i != -1 {
+		return ident[:i]
 	}
+	return ident
+}
 
-	url := strings.TrimSpace(string(output))
-	parts := strings.Split(url, "/")
+func getContributors(dir, revisionRange string) string {
+	authors := gitOutput(dir, "log", "--format=%an", revisionRange)
+	if authors == "" {
+		return ""
+	}
 
-	remote := parts[len(parts)-1]
-	return strings.TrimSuffix(remote, ".git")
+	var contributors []string
+	seen := make(map[string]bool)
+	for _, author := range strings.Split(authors, "\n") {
+		if !seen[author] {
+			seen[author] = true
+			contributors = append(contributors, author)
+		}
+	}
+	sort.Strings(contributors)
+	return strings.Join(contributors, ", ")
 }
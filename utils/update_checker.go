@@ -44,11 +44,13 @@ func init() {
 	UpdateChecker()
 }
 
+// UpdateChecker notifies about a newer release. The notices go to stderr so
+// they don't mix with machine-readable output.
 func UpdateChecker() {
 	// Read the cached version and publication time
 	cachedConfig, err := ReadConfigFile()
 	if err != nil {
-		fmt.Println("Failed to read cached config:", err)
+		fmt.Fprintln(os.Stderr, "Failed to read cached config:", err)
 	}
 
 	// Check if the cached version is up-to-date
@@ -60,28 +62,28 @@ func UpdateChecker() {
 	url := fmt.Sprintf(releasesAPI, repoOwner, repoName)
 	resp, err := http.Get(url)
 	if err != nil {
-		fmt.Println("Failed to check for new version:", err)
+		fmt.Fprintln(os.Stderr, "Failed to check for new version:", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Println("Failed to read response body:", err)
+		fmt.Fprintln(os.Stderr, "Failed to read response body:", err)
 		return
 	}
 
 	var rel release
 	err = json.Unmarshal(body, &rel)
 	if err != nil {
-		fmt.Println("Failed to parse response body:", err)
+		fmt.Fprintln(os.Stderr, "Failed to parse response body:", err)
 		return
 	}
 
 	latestVersion = rel.TagName
 
 	if compareVersions(latestVersion, currentVersion) > 0 {
-		fmt.Printf(color.RedString("A newer version (%s) of the CLI is available. Please update to the latest version.")+color.GreenString("\nhttps://github.com/arzkar/git-utils#installation\n"), latestVersion)
+		fmt.Fprintf(os.Stderr, color.RedString("A newer version (%s) of the CLI is available. Please update to the latest version.")+color.GreenString("\nhttps://github.com/arzkar/git-utils#installation\n"), latestVersion)
 	}
 
 	// Update the latest version and publication time in the config file
@@ -90,7 +92,7 @@ func UpdateChecker() {
 		config.LastUpdated = time.Now()
 	})
 	if err != nil {
-		fmt.Println("Failed to update the config:", err)
+		fmt.Fprintln(os.Stderr, "Failed to update the config:", err)
 	}
 }
 
@@ -100,13 +102,6 @@ func compareVersions(v1, v2 string) int {
 	return strings.Compare(v1, v2)
 }
 
-func ParseTemplate(template string, variables map[string]string) string {
-	for key, value := range variables {
-		template = strings.ReplaceAll(template, "{"+key+"}", value)
-	}
-	return template
-}
-
 func ReadConfigFile() (Config, error) {
 	config := Config{}
 	filePath := GetConfigFilePath()
@@ -138,13 +133,9 @@ func ReadConfigFile() (Config, error) {
 }
 
 func createDefaultConfigFile() error {
-	config := Config{
-		Tags: struct {
-			Messages map[string]string `json:"messages"`
-		}{
-			Messages: make(map[string]string),
-		},
-	}
+	config := Config{}
+	config.Tags.Messages = make(map[string]string)
+	config.Grep.Queries = make(map[string]GrepQuery)
 	data, err := json.MarshalIndent(config, "", "    ")
 	if err != nil {
 		return err
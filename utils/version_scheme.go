@@ -0,0 +1,228 @@
+/*
+Copyright 2023 Arbaaz Laskar
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-ini/ini"
+)
+
+const partSectionPrefix = "bumpversion:part:"
+
+var serializePlaceholderRegex = regexp.MustCompile(`\{(\w+)\}`)
+
+type versionPart struct {
+	// values lists the allowed values in order, numeric parts have none
+	values        []string
+	firstValue    string
+	optionalValue string
+}
+
+// VersionScheme is a bump2version style version scheme defined with the
+// parse and serialize keys and [bumpversion:part:<name>] sections
+type VersionScheme struct {
+	parse     *regexp.Regexp
+	serialize []string
+	// parts are the named groups of parse, in order
+	parts  []string
+	config map[string]versionPart
+}
+
+// loadVersionScheme returns the custom version scheme of the config, or nil
+// when no parse key is set and semantic versioning is used
+func loadVersionScheme(config *ini.File) (*VersionScheme, error) {
+	section := config.Section("bumpversion")
+	if !section.HasKey("parse") {
+		return nil, nil
+	}
+
+	parse, err := regexp.Compile(`^(?:` + strings.TrimSpace(section.Key("parse").String()) + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parse regex in %s: %w", bump_cfg, err)
+	}
+
+	scheme := &VersionScheme{
+		parse:     parse,
+		serialize: splitListValue(section.Key("serialize").String()),
+		config:    make(map[string]versionPart),
+	}
+	for _, name := range parse.SubexpNames() {
+		if name != "" {
+			scheme.parts = append(scheme.parts, name)
+		}
+	}
+	if len(scheme.parts) == 0 {
+		return nil, fmt.Errorf("the parse regex in %s has no named groups such as (?P<major>\\d+)", bump_cfg)
+	}
+
+	// Without serialize, the parts are joined with dots
+	if len(scheme.serialize) == 0 {
+		var placeholders []string
+		for _, name := range scheme.parts {
+			placeholders = append(placeholders, "{"+name+"}")
+		}
+		scheme.serialize = []string{strings.Join(placeholders, ".")}
+	}
+	for _, format := range scheme.serialize {
+		for _, match := range serializePlaceholderRegex.FindAllStringSubmatch(format, -1) {
+			if indexOf(scheme.parts, match[1]) == -1 {
+				return nil, fmt.Errorf("serialize format '%s' in %s uses unknown part '%s'", format, bump_cfg, match[1])
+			}
+		}
+	}
+
+	for _, name := range scheme.parts {
+		partSection := config.Section(partSectionPrefix + name)
+		part := versionPart{
+			values:     splitListValue(partSection.Key("values").String()),
+			firstValue: partSection.Key("first_value").String(),
+		}
+		if part.firstValue == "" {
+			part.firstValue = "0"
+			if len(part.values) > 0 {
+				part.firstValue = part.values[0]
+			}
+		}
+		part.optionalValue = part.firstValue
+		if partSection.HasKey("optional_value") {
+			part.optionalValue = partSection.Key("optional_value").String()
+		}
+		scheme.config[name] = part
+	}
+
+	return scheme, nil
+}
+
+// Parse returns the value of every part of the version
+func (s *VersionScheme) Parse(version string) (map[string]string, error) {
+	matches := s.parse.FindStringSubmatch(version)
+	if matches == nil {
+		return nil, fmt.Errorf("version '%s' doesn't match the parse regex in %s", version, bump_cfg)
+	}
+
+	values := make(map[string]string)
+	for i, name := range s.parse.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		values[name] = matches[i]
+		// Parts missing from the version have their optional value
+		if values[name] == "" {
+			values[name] = s.config[name].optionalValue
+		}
+	}
+	return values, nil
+}
+
+// Bump increments the part and resets all the following parts
+func (s *VersionScheme) Bump(version, part string) (string, error) {
+	values, err := s.Parse(version)
+	if err != nil {
+		return "", err
+	}
+
+	index := indexOf(s.parts, part)
+	if index == -1 {
+		return "", fmt.Errorf("unknown version part '%s', expected one of: %s", part, strings.Join(s.parts, ", "))
+	}
+
+	values[part], err = s.config[part].next(part, values[part])
+	if err != nil {
+		return "", err
+	}
+	for _, name := range s.parts[index+1:] {
+		values[name] = s.config[name].firstValue
+	}
+
+	return s.Serialize(values), nil
+}
+
+// LastPart returns the last part of the version, bumped by default
+func (s *VersionScheme) LastPart() string {
+	return s.parts[len(s.parts)-1]
+}
+
+// Serialize formats the values with the last serialize format that
+// contains every part that doesn't have its optional value, so formats are
+// listed from the most to the least complete like bump2version
+func (s *VersionScheme) Serialize(values map[string]string) string {
+	chosen := s.serialize[0]
+	for _, format := range s.serialize {
+		used := make(map[string]bool)
+		for _, match := range serializePlaceholderRegex.FindAllStringSubmatch(format, -1) {
+			used[match[1]] = true
+		}
+
+		complete := true
+		for _, name := range s.parts {
+			if !used[name] && values[name] != s.config[name].optionalValue {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			chosen = format
+		}
+	}
+
+	return formatVersion(chosen, values)
+}
+
+func formatVersion(format string, values map[string]string) string {
+	return serializePlaceholderRegex.ReplaceAllStringFunc(format, func(placeholder string) string {
+		return values[strings.Trim(placeholder, "{}")]
+	})
+}
+
+func (p versionPart) next(name, value string) (string, error) {
+	if len(p.values) == 0 {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("version part '%s' has non-numeric value '%s', set its values in [%s%s]", name, value, partSectionPrefix, name)
+		}
+		return strconv.Itoa(number + 1), nil
+	}
+
+	index := indexOf(p.values, value)
+	if index == -1 {
+		return "", fmt.Errorf("version part '%s' has value '%s' which isn't one of: %s", name, value, strings.Join(p.values, ", "))
+	}
+	if index == len(p.values)-1 {
+		return "", fmt.Errorf("version part '%s' is already at its last value '%s'", name, value)
+	}
+	return p.values[index+1], nil
+}
+
+// splitListValue splits a multi-line or comma-separated config value
+func splitListValue(value string) []string {
+	separator := ","
+	if strings.Contains(value, "\n") {
+		separator = "\n"
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, separator) {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}